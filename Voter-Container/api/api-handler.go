@@ -2,8 +2,11 @@ package api
 
 import (
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"drexel.edu/voter/db"
@@ -13,49 +16,258 @@ import (
 // The api package creates and maintains a reference to the data handler
 // this is a good design practice
 type VoterAPI struct {
-	db         *db.VoterList
-	bootTime   time.Time
-	totalCalls uint64
-	errors     map[int]uint64
+	db       db.VoterStore
+	bootTime time.Time
+
+	metrics *metrics
+
+	// draining is set once the server starts shutting down
+	draining atomic.Bool
 }
 
-func New() (*VoterAPI, error) {
-	dbHandler, err := db.NewVoterList()
+// New creates the api on top of store, which can be any of the VoterStore
+// implementations in the db package.  The store reports its votes and
+// redis commands to the api's metrics.
+func New(store db.VoterStore) *VoterAPI {
+	m := newMetrics()
+	store.Observe(m.observer())
+
+	return &VoterAPI{db: store, bootTime: time.Now(), metrics: m}
+}
+
+// statusFor picks the status code to report for an error from the db
+// layer.  Conflicts with existing votes are reported as 409 Conflict,
+// changes to a stale version of a voter as 412 Precondition Failed, a store
+// that did not answer in time as 504 Gateway Timeout, one that could not be
+// reached as 503 Service Unavailable and anything else gets the fallback
+// status.
+func statusFor(err error, fallback int) int {
+	switch {
+	case errors.Is(err, db.ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, db.ErrUnavailable), errors.Is(err, db.ErrBusy):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, db.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, db.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	}
+	return fallback
+}
+
+// Voters are listed a page at a time.  The limit query parameter sets the
+// page size and cursor is the voter id the page starts at.
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// implementation of GET /voters?limit=&cursor=.  The link to the next page
+// is sent in the Link header, or as the next link of a HAL document.
+// sort orders the voters by voter_id, name or email, with a leading - for
+// descending order, and voted_in and voted_after only list the voters with
+// a vote in the poll or a vote cast after the date.  Lists in any order but
+// voter id are paged with offset rather than cursor.
+// GET /voters?email= lists the voter with that email instead.
+func (v *VoterAPI) ListAllVoters(c *fiber.Ctx) error {
+	query := struct {
+		Limit      int    `query:"limit"`
+		Cursor     *uint  `query:"cursor"`
+		Offset     *int   `query:"offset"`
+		Email      string `query:"email"`
+		Sort       string `query:"sort"`
+		VotedIn    *uint  `query:"voted_in"`
+		VotedAfter string `query:"voted_after"`
+	}{}
+
+	if err := c.QueryParser(&query); err != nil {
+		logger(c).Warn("Error parsing query", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+	if query.Email != "" {
+		return v.listVotersByEmail(c, query.Email)
+	}
+
+	var voterQuery db.VoterQuery
+	var err error
+	if voterQuery.Limit, err = pageLimit(query.Limit); err != nil {
+		return err
+	}
+	if voterQuery.Sort, err = db.ParseVoterSort(query.Sort); err != nil {
+		return err
+	}
+	voterQuery.VotedIn = query.VotedIn
+	if query.VotedAfter != "" {
+		votedAfter, err := parseQueryTime("voted_after", query.VotedAfter)
+		if err != nil {
+			return err
+		}
+		voterQuery.VotedAfter = &votedAfter
+	}
+
+	if voterQuery.ByCursor() && query.Offset != nil {
+		return fiber.NewError(http.StatusBadRequest, "voters in voter id order are paged with cursor, not offset")
+	}
+	if !voterQuery.ByCursor() && query.Cursor != nil {
+		return fiber.NewError(http.StatusBadRequest, "sorted voters are paged with offset, not cursor")
+	}
+	if query.Cursor != nil {
+		voterQuery.Cursor = *query.Cursor
+	}
+	if query.Offset != nil {
+		if *query.Offset < 0 {
+			return fiber.NewError(http.StatusBadRequest, "offset cannot be negative")
+		}
+		if *query.Offset >= db.MaxVoterOffset {
+			return fiber.NewError(http.StatusBadRequest,
+				fmt.Sprintf("offset must be less than %d, narrow the voters with voted_in or voted_after", db.MaxVoterOffset))
+		}
+		voterQuery.Offset = *query.Offset
+	}
+
+	page, err := v.db.GetVoterPage(c.UserContext(), voterQuery)
 	if err != nil {
-		return nil, err
+		logger(c).Error("Error getting all voters", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
+			"Error Getting All Voters")
+	}
+	if page.Voters == nil {
+		page.Voters = make([]db.Voter, 0)
 	}
 
-	return &VoterAPI{db: dbHandler, bootTime: time.Now(), errors: make(map[int]uint64)}, nil
+	self := "/voters"
+	if query.Cursor != nil || query.Offset != nil || query.Sort != "" || voterQuery.Filtered() {
+		self = votersPageUrl(voterQuery, voterQuery.Cursor+uint(voterQuery.Offset))
+	}
+	next := ""
+	if page.More {
+		next = votersPageUrl(voterQuery, page.Next)
+		c.Links(next, "next")
+	}
+
+	if notModified(c, votersETag(page.Voters)) {
+		return nil
+	}
+
+	return render(c, page.Voters, func() interface{} { return newVotersResource(page.Voters, self, next) })
 }
 
-func (v *VoterAPI) HandleStats(c *fiber.Ctx) error {
-	v.totalCalls++
-	err := c.Next()
+// parseQueryTime reads a time query parameter, either an RFC 3339 time or
+// just a date
+func parseQueryTime(name string, value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, &db.ValidationError{Fields: []db.FieldError{{
+		Field:  name,
+		Reason: "must be an RFC 3339 time or a date like 2024-01-31",
+	}}}
+}
 
-	var e *fiber.Error
-	if errors.As(err, &e) {
-		v.errors[e.Code]++
+// pageLimit checks the limit query parameter, which is the default when
+// it was left out
+func pageLimit(limit int) (int, error) {
+	if limit == 0 {
+		return defaultPageLimit, nil
+	}
+	if limit < 0 || limit > maxPageLimit {
+		return 0, fiber.NewError(http.StatusBadRequest,
+			fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
 	}
-	return err
+	return limit, nil
 }
 
-func (v *VoterAPI) ListAllVoters(c *fiber.Ctx) error {
-	voterList, err := v.db.GetAllVoters()
+// votersPageUrl is the url of the page of voters for the query that starts
+// at position, a voter id cursor or an offset depending on the sort
+func votersPageUrl(query db.VoterQuery, position uint) string {
+	params := []string{fmt.Sprintf("limit=%d", query.Limit)}
+	if !query.ByCursor() {
+		params = append(params, "sort="+query.Sort.String())
+	}
+	if query.VotedIn != nil {
+		params = append(params, fmt.Sprintf("voted_in=%d", *query.VotedIn))
+	}
+	if query.VotedAfter != nil {
+		params = append(params, "voted_after="+url.QueryEscape(query.VotedAfter.Format(time.RFC3339Nano)))
+	}
+
+	if query.ByCursor() {
+		params = append(params, fmt.Sprintf("cursor=%d", position))
+	} else {
+		params = append(params, fmt.Sprintf("offset=%d", position))
+	}
+	return "/voters?" + strings.Join(params, "&")
+}
+
+// listVotersByEmail sends the voter with the email as a list, which is
+// empty when no voter has it, so the response has the same shape as any
+// other list of voters
+func (v *VoterAPI) listVotersByEmail(c *fiber.Ctx, email string) error {
+	voters := make([]db.Voter, 0, 1)
+	voter, err := v.db.GetVoterByEmail(c.UserContext(), email)
+	switch {
+	case errors.Is(err, db.ErrTimeout), errors.Is(err, db.ErrUnavailable):
+		logger(c).Error("Error getting voter by email", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	case err != nil:
+		logger(c).Debug("No voter for email", "error", err)
+	default:
+		voters = append(voters, voter)
+	}
+
+	if notModified(c, votersETag(voters)) {
+		return nil
+	}
+
+	self := "/voters?email=" + url.QueryEscape(email)
+	return render(c, voters, func() interface{} { return newVotersResource(voters, self, "") })
+}
+
+// implementation of GET /voters/search?q=&limit=, which finds voters by the
+// start of the words in their name or email.  Matches are sent in voter id
+// order, up to limit of them.
+func (v *VoterAPI) SearchVoters(c *fiber.Ctx) error {
+	query := struct {
+		Q     string `query:"q"`
+		Limit int    `query:"limit"`
+	}{}
+
+	if err := c.QueryParser(&query); err != nil {
+		logger(c).Warn("Error parsing query", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+	limit, err := pageLimit(query.Limit)
 	if err != nil {
-		log.Println("Error getting all voters: ", err)
-		return fiber.NewError(http.StatusNotFound,
-			"Error Getting All Voters")
+		return err
+	}
+
+	voters, err := v.db.SearchVoters(c.UserContext(), query.Q, limit)
+	var validationError *db.ValidationError
+	if errors.As(err, &validationError) {
+		return err
+	}
+	if err != nil {
+		logger(c).Error("Error searching voters", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError), "Error Searching Voters")
 	}
-	if voterList == nil {
-		voterList = make([]db.Voter, 0)
+	if voters == nil {
+		voters = make([]db.Voter, 0)
 	}
-	return c.JSON(voterList)
+
+	if notModified(c, votersETag(voters)) {
+		return nil
+	}
+
+	self := "/voters/search?q=" + url.QueryEscape(query.Q)
+	return render(c, voters, func() interface{} { return newVotersResource(voters, self, "") })
 }
 
 func (v *VoterAPI) DeleteAllVoters(c *fiber.Ctx) error {
-	if err := v.db.DeleteAll(); err != nil {
-		log.Println("Error deleting all voters: ", err)
-		return fiber.NewError(http.StatusNotFound,
+	if err := v.db.DeleteAll(c.UserContext()); err != nil {
+		logger(c).Error("Error deleting all voters", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
 			"Error Deleting All Voters")
 	}
 
@@ -72,10 +284,10 @@ func (v *VoterAPI) withVoter(c *fiber.Ctx, run func(voter db.Voter) error) error
 		return fiber.NewError(http.StatusBadRequest)
 	}
 
-	voter, err := v.db.GetVoter(param.ID)
+	voter, err := v.db.GetVoter(c.UserContext(), param.ID)
 	if err != nil {
-		log.Println("Voter not found: ", err)
-		return fiber.NewError(http.StatusNotFound)
+		logger(c).Warn("Voter not found", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound))
 	}
 
 	return run(voter)
@@ -83,7 +295,10 @@ func (v *VoterAPI) withVoter(c *fiber.Ctx, run func(voter db.Voter) error) error
 
 func (v *VoterAPI) GetVoter(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
-		return c.JSON(voter)
+		if notModified(c, voterETag(voter.Version)) {
+			return nil
+		}
+		return render(c, voter, func() interface{} { return newVoterResource(voter) })
 	})
 }
 
@@ -91,21 +306,23 @@ func (v *VoterAPI) AddVoter(c *fiber.Ctx) error {
 	var voter db.Voter
 
 	if err := c.BodyParser(&voter); err != nil {
-		log.Println("Error binding JSON: ", err)
+		logger(c).Warn("Error binding JSON", "error", err)
 		return fiber.NewError(http.StatusBadRequest)
 	}
 
-	if err := voter.ValidateVotes(); err != nil {
-		log.Println("Voter is not valid.")
-		return fiber.NewError(http.StatusBadRequest)
+	if err := voter.Validate(); err != nil {
+		logger(c).Warn("Voter is not valid", "error", err)
+		return err
 	}
 
-	if err := v.db.AddVoter(voter); err != nil {
-		log.Println("Error adding voter: ", err)
-		return fiber.NewError(http.StatusInternalServerError)
+	stored, err := v.db.AddVoter(c.UserContext(), voter)
+	if err != nil {
+		logger(c).Error("Error adding voter", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
 	}
+	c.Set(fiber.HeaderETag, voterETag(stored.Version))
 
-	return c.JSON(voter)
+	return render(c, stored, func() interface{} { return newVoterResource(stored) })
 }
 
 func (v *VoterAPI) UpdateVoter(c *fiber.Ctx) error {
@@ -120,26 +337,34 @@ func (v *VoterAPI) UpdateVoter(c *fiber.Ctx) error {
 	var voter db.Voter
 
 	if err := c.BodyParser(&voter); err != nil {
-		log.Println("Error binding JSON: ", err)
+		logger(c).Warn("Error binding JSON", "error", err)
 		return fiber.NewError(http.StatusBadRequest)
 	}
 
 	if voter.VoterId != param.ID {
-		log.Println("Voter does not match id parameter.")
+		logger(c).Warn("Voter does not match id parameter")
 		return fiber.NewError(http.StatusBadRequest)
 	}
 
-	if err := voter.ValidateVotes(); err != nil {
-		log.Println("Voter is not valid.")
-		return fiber.NewError(http.StatusBadRequest)
+	if err := voter.Validate(); err != nil {
+		logger(c).Warn("Voter is not valid", "error", err)
+		return err
 	}
 
-	if err := v.db.UpdateVoter(voter); err != nil {
-		log.Println("Error updating voter: ", err)
-		return fiber.NewError(http.StatusInternalServerError)
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	voter.Version = version
+
+	stored, err := v.db.UpdateVoter(c.UserContext(), voter)
+	if err != nil {
+		logger(c).Error("Error updating voter", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
 	}
+	c.Set(fiber.HeaderETag, voterETag(stored.Version))
 
-	return c.JSON(voter)
+	return render(c, stored, func() interface{} { return newVoterResource(stored) })
 }
 
 func (v *VoterAPI) DeleteVoter(c *fiber.Ctx) error {
@@ -151,50 +376,67 @@ func (v *VoterAPI) DeleteVoter(c *fiber.Ctx) error {
 		return fiber.NewError(http.StatusBadRequest)
 	}
 
-	if err := v.db.DeleteVoter(param.ID); err != nil {
-		log.Println("Error deleting voter: ", err)
-		return fiber.NewError(http.StatusInternalServerError)
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := v.db.DeleteVoter(c.UserContext(), param.ID, version); err != nil {
+		logger(c).Error("Error deleting voter", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
 	}
 
 	return c.Status(http.StatusOK).SendString("Delete OK")
 }
 
-func (v *VoterAPI) GetAllVotes(c *fiber.Ctx) error {
+func (v *VoterAPI) GetAllVoterPolls(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
-		return c.JSON(voter.VoteHistory)
+		if notModified(c, voterETag(voter.Version)) {
+			return nil
+		}
+		return render(c, voter.VoteHistory, func() interface{} { return newVoterPollsResource(voter) })
 	})
 }
 
-func (v *VoterAPI) AddVote(c *fiber.Ctx) error {
+func (v *VoterAPI) AddVoterPoll(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
 		var vote db.VoterHistory
 
 		if err := c.BodyParser(&vote); err != nil {
-			log.Println("Error binding JSON: ", err)
+			logger(c).Warn("Error binding JSON", "error", err)
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
 		if voter.VoterId != vote.VoterId {
-			log.Println("Voter id does not match voter_id.")
+			logger(c).Warn("Voter id does not match voter_id")
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
-		voter, err := voter.AddVote(vote)
+		if err := vote.Validate(); err != nil {
+			logger(c).Warn("Vote is not valid", "error", err)
+			return err
+		}
+
+		version, err := ifMatch(c)
 		if err != nil {
-			log.Println("Error adding vote to voter: ", err)
-			return fiber.NewError(http.StatusInternalServerError)
+			return err
 		}
 
-		if err := v.db.UpdateVoter(voter); err != nil {
-			log.Println("Error updating voter with vote: ", err)
-			return fiber.NewError(http.StatusInternalServerError)
+		newVote := vote.ToVote()
+		newVote.Version = version
+		added, err := v.db.AddVote(c.UserContext(), newVote)
+		if err != nil {
+			logger(c).Error("Error adding vote to voter", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
 		}
+		c.Set(fiber.HeaderETag, voterETag(added.Version))
 
-		return c.JSON(vote)
+		history := added.ToHistory()
+		return render(c, history, func() interface{} { return newVoterPoll(history) })
 	})
 }
 
-func (v *VoterAPI) GetVote(c *fiber.Ctx) error {
+func (v *VoterAPI) GetVoterPoll(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
 		param := struct {
 			ID uint `params:"pollid"`
@@ -206,15 +448,18 @@ func (v *VoterAPI) GetVote(c *fiber.Ctx) error {
 
 		vote, err := voter.GetVote(param.ID)
 		if err != nil {
-			log.Println("Error getting vote: ", err)
+			logger(c).Warn("Error getting vote", "error", err)
 			return fiber.NewError(http.StatusNotFound)
 		}
 
-		return c.JSON(vote)
+		if notModified(c, voterETag(voter.Version)) {
+			return nil
+		}
+		return render(c, vote, func() interface{} { return newVoterPoll(vote) })
 	})
 }
 
-func (v *VoterAPI) UpdateVote(c *fiber.Ctx) error {
+func (v *VoterAPI) UpdateVoterPoll(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
 		param := struct {
 			ID uint `params:"pollid"`
@@ -227,36 +472,56 @@ func (v *VoterAPI) UpdateVote(c *fiber.Ctx) error {
 		var vote db.VoterHistory
 
 		if err := c.BodyParser(&vote); err != nil {
-			log.Println("Error binding JSON: ", err)
+			logger(c).Warn("Error binding JSON", "error", err)
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
 		if vote.PollId != param.ID {
-			log.Println("Poll Id does not match pollid parameter")
+			logger(c).Warn("Poll Id does not match pollid parameter")
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
 		if voter.VoterId != vote.VoterId {
-			log.Println("Voter does not match voter_id.")
+			logger(c).Warn("Voter does not match voter_id")
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
-		voter, err := voter.UpdateVote(vote)
+		if err := vote.Validate(); err != nil {
+			logger(c).Warn("Vote is not valid", "error", err)
+			return err
+		}
+
+		existing, err := voter.GetVote(param.ID)
 		if err != nil {
-			log.Println("Error updating vote: ", err)
+			logger(c).Error("Error updating vote", "error", err)
 			return fiber.NewError(http.StatusInternalServerError)
 		}
 
-		if err := v.db.UpdateVoter(voter); err != nil {
-			log.Println("Error updating voter: ", err)
-			return fiber.NewError(http.StatusInternalServerError)
+		if vote.VoteId != 0 && vote.VoteId != existing.VoteId {
+			logger(c).Warn("Vote id does not match existing vote")
+			return fiber.NewError(http.StatusBadRequest)
 		}
+		vote.VoteId = existing.VoteId
 
-		return c.JSON(vote)
+		version, err := ifMatch(c)
+		if err != nil {
+			return err
+		}
+
+		updated := vote.ToVote()
+		updated.Version = version
+		stored, err := v.db.UpdateVote(c.UserContext(), updated)
+		if err != nil {
+			logger(c).Error("Error updating vote", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+		}
+		c.Set(fiber.HeaderETag, voterETag(stored.Version))
+
+		return render(c, vote, func() interface{} { return newVoterPoll(vote) })
 	})
 }
 
-func (v *VoterAPI) DeleteVote(c *fiber.Ctx) error {
+func (v *VoterAPI) DeleteVoterPoll(c *fiber.Ctx) error {
 	return v.withVoter(c, func(voter db.Voter) error {
 		param := struct {
 			ID uint `params:"pollid"`
@@ -266,33 +531,23 @@ func (v *VoterAPI) DeleteVote(c *fiber.Ctx) error {
 			return fiber.NewError(http.StatusBadRequest)
 		}
 
-		voter, err := voter.DeleteVote(param.ID)
+		vote, err := voter.GetVote(param.ID)
 		if err != nil {
-			log.Println("Error deleting vote: ", err)
+			logger(c).Error("Error deleting vote", "error", err)
 			return fiber.NewError(http.StatusInternalServerError)
 		}
 
-		if err := v.db.UpdateVoter(voter); err != nil {
-			log.Println("Error updating voter: ", err)
-			return fiber.NewError(http.StatusInternalServerError)
+		version, err := ifMatch(c)
+		if err != nil {
+			return err
+		}
+
+		if err := v.db.DeleteVote(c.UserContext(), vote.VoteId, version); err != nil {
+			logger(c).Error("Error deleting vote", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
 		}
 
 		return c.Status(http.StatusOK).SendString("Delete OK")
 
 	})
 }
-
-// implementation of GET /health. It is a good practice to build in a
-// health check for your API.  Below the results are just hard coded
-// but in a real API you can provide detailed information about the
-// health of your API with a Health Check
-func (v *VoterAPI) HealthCheck(c *fiber.Ctx) error {
-	return c.Status(http.StatusOK).
-		JSON(fiber.Map{
-			"status":             "ok",
-			"version":            "1.0.0",
-			"uptime":             time.Now().Sub(v.bootTime),
-			"total_calls":        v.totalCalls,
-			"errors_encountered": v.errors,
-		})
-}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext makes ctx the parent of every request's user context, the
+// one handed to the store, so cancelling ctx stops the store calls of the
+// requests still running.  It has to come before LogRequests, which adds to
+// the user context.
+//
+// fasthttp, which fiber is built on, does not tell a handler when its
+// client hangs up, so the context is not cancelled when that happens.
+func RequestContext(ctx context.Context) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
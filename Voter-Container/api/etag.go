@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Every voter has a version that goes up each time it is stored.  The
+// version is sent as the voter's ETag, and as the ETag of the votes in its
+// history, so clients can make conditional requests with If-Match and
+// If-None-Match.
+
+// voterETag is the strong ETag for a voter at version
+func voterETag(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// votersETag is a weak ETag for a page of voters.  It changes whenever a
+// voter on the page does.
+func votersETag(voters []db.Voter) string {
+	h := fnv.New64a()
+	for _, voter := range voters {
+		fmt.Fprintf(h, "%d:%d;", voter.VoterId, voter.Version)
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// notModified sets the ETag header and reports if the client already has
+// that version from its If-None-Match header, in which case the response
+// has been set to 304 Not Modified
+func notModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+
+	match := c.Get(fiber.HeaderIfNoneMatch)
+	if match == "" {
+		return false
+	}
+
+	// If-None-Match uses the weak comparison, so W/ is ignored
+	for _, tag := range strings.Split(match, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the voter version the client expects from the If-Match
+// header, or db.AnyVersion when there is no If-Match or it is *.  If-Match
+// uses the strong comparison, so a weak or malformed tag can never match
+// and fails with 412 Precondition Failed.
+func ifMatch(c *fiber.Ctx) (uint64, error) {
+	match := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if match == "" || match == "*" {
+		return db.AnyVersion, nil
+	}
+
+	if len(match) < 2 || match[0] != '"' || match[len(match)-1] != '"' {
+		return 0, fiber.NewError(http.StatusPreconditionFailed)
+	}
+	version, err := strconv.ParseUint(match[1:len(match)-1], 10, 64)
+	if err != nil || version == db.AnyVersion {
+		return 0, fiber.NewError(http.StatusPreconditionFailed)
+	}
+	return version, nil
+}
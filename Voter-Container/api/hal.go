@@ -0,0 +1,326 @@
+package api
+
+import (
+	"fmt"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// The structures below render the resources as Hypermedia Application
+// Language (HAL) documents, see papers/voting-api.md.  They are only used
+// when the client asks for application/hal+json, plain JSON remains the
+// default so existing clients keep working.
+const halContentType = "application/hal+json"
+
+type link struct {
+	Href string `json:"href"`
+}
+
+type selfLink struct {
+	Self link `json:"self"`
+}
+
+func newLink(format string, args ...interface{}) link {
+	return link{Href: fmt.Sprintf(format, args...)}
+}
+
+// wantsHAL reports if the client prefers HAL over plain JSON
+func wantsHAL(c *fiber.Ctx) bool {
+	c.Vary(fiber.HeaderAccept)
+	return c.Accepts(fiber.MIMEApplicationJSON, halContentType) == halContentType
+}
+
+// render sends the plain value, or the HAL resource built by hal if the
+// client asked for it
+func render(c *fiber.Ctx, plain interface{}, hal func() interface{}) error {
+	if wantsHAL(c) {
+		return c.JSON(hal(), halContentType)
+	}
+	return c.JSON(plain)
+}
+
+type homeLinks struct {
+	selfLink
+	Voters link `json:"voters"`
+	Polls  link `json:"polls"`
+	Votes  link `json:"votes"`
+}
+
+type home struct {
+	Links homeLinks `json:"_links"`
+}
+
+// implementation of GET /.  The home document is the starting point for
+// navigating the API.
+func (v *VoterAPI) Home(c *fiber.Ctx) error {
+	doc := home{
+		Links: homeLinks{
+			selfLink: selfLink{Self: newLink("/")},
+			Voters:   newLink("/voters"),
+			Polls:    newLink("/polls"),
+			Votes:    newLink("/votes"),
+		},
+	}
+	return render(c, doc, func() interface{} { return doc })
+}
+
+// Voters
+
+type voterPollLinks struct {
+	selfLink
+	Poll link `json:"poll"`
+	Vote link `json:"vote"`
+}
+
+type voterPoll struct {
+	Links voterPollLinks `json:"_links"`
+	db.VoterHistory
+}
+
+type voterEmbedded struct {
+	VoterHistory []voterPoll `json:"voter_history"`
+}
+
+type voterLinks struct {
+	selfLink
+	VoterHistory link `json:"voter_history"`
+}
+
+type voterResource struct {
+	Links    voterLinks    `json:"_links"`
+	Embedded voterEmbedded `json:"_embedded"`
+	VoterId  uint          `json:"voter_id"`
+	Name     string        `json:"name"`
+	Email    string        `json:"email"`
+}
+
+type votersEmbedded struct {
+	Voters []voterResource `json:"voters"`
+}
+
+type votersLinks struct {
+	selfLink
+	Next *link `json:"next,omitempty"`
+}
+
+type votersResource struct {
+	Links    votersLinks    `json:"_links"`
+	Embedded votersEmbedded `json:"_embedded"`
+}
+
+type voterPollsLinks struct {
+	selfLink
+	Voter link `json:"voter"`
+}
+
+type voterPollsResource struct {
+	Links    voterPollsLinks `json:"_links"`
+	Embedded voterEmbedded   `json:"_embedded"`
+}
+
+func newVoterPoll(vote db.VoterHistory) voterPoll {
+	return voterPoll{
+		Links: voterPollLinks{
+			selfLink: selfLink{Self: newLink("/voters/%d/polls/%d", vote.VoterId, vote.PollId)},
+			Poll:     newLink("/polls/%d", vote.PollId),
+			Vote:     newLink("/votes/%d", vote.VoteId),
+		},
+		VoterHistory: vote,
+	}
+}
+
+func newVoterPolls(history []db.VoterHistory) []voterPoll {
+	polls := make([]voterPoll, 0, len(history))
+	for _, vote := range history {
+		polls = append(polls, newVoterPoll(vote))
+	}
+	return polls
+}
+
+func newVoterResource(voter db.Voter) voterResource {
+	return voterResource{
+		Links: voterLinks{
+			selfLink:     selfLink{Self: newLink("/voters/%d", voter.VoterId)},
+			VoterHistory: newLink("/voters/%d/polls", voter.VoterId),
+		},
+		Embedded: voterEmbedded{VoterHistory: newVoterPolls(voter.VoteHistory)},
+		VoterId:  voter.VoterId,
+		Name:     voter.Name,
+		Email:    voter.Email,
+	}
+}
+
+// newVotersResource builds a page of voters, next is empty on the last page
+func newVotersResource(voters []db.Voter, self string, next string) votersResource {
+	resources := make([]voterResource, 0, len(voters))
+	for _, voter := range voters {
+		resources = append(resources, newVoterResource(voter))
+	}
+
+	links := votersLinks{selfLink: selfLink{Self: link{Href: self}}}
+	if next != "" {
+		links.Next = &link{Href: next}
+	}
+
+	return votersResource{
+		Links:    links,
+		Embedded: votersEmbedded{Voters: resources},
+	}
+}
+
+func newVoterPollsResource(voter db.Voter) voterPollsResource {
+	return voterPollsResource{
+		Links: voterPollsLinks{
+			selfLink: selfLink{Self: newLink("/voters/%d/polls", voter.VoterId)},
+			Voter:    newLink("/voters/%d", voter.VoterId),
+		},
+		Embedded: voterEmbedded{VoterHistory: newVoterPolls(voter.VoteHistory)},
+	}
+}
+
+// Polls
+
+type pollOptionLinks struct {
+	selfLink
+	Poll link `json:"poll"`
+}
+
+type pollOption struct {
+	Links pollOptionLinks `json:"_links"`
+	db.PollOption
+}
+
+type pollEmbedded struct {
+	PollOptions []pollOption `json:"poll_options"`
+}
+
+type pollLinks struct {
+	selfLink
+	PollOptions link `json:"poll_options"`
+}
+
+type pollResource struct {
+	Links        pollLinks    `json:"_links"`
+	Embedded     pollEmbedded `json:"_embedded"`
+	PollId       uint         `json:"poll_id"`
+	PollTitle    string       `json:"poll_title"`
+	PollQuestion string       `json:"poll_question"`
+}
+
+type pollsEmbedded struct {
+	Polls []pollResource `json:"polls"`
+}
+
+type pollsResource struct {
+	Links    selfLink      `json:"_links"`
+	Embedded pollsEmbedded `json:"_embedded"`
+}
+
+type pollOptionsLinks struct {
+	selfLink
+	Poll link `json:"poll"`
+}
+
+type pollOptionsResource struct {
+	Links    pollOptionsLinks `json:"_links"`
+	Embedded pollEmbedded     `json:"_embedded"`
+}
+
+func newPollOption(pollId uint, option db.PollOption) pollOption {
+	return pollOption{
+		Links: pollOptionLinks{
+			selfLink: selfLink{Self: newLink("/polls/%d/options/%d", pollId, option.PollOptionId)},
+			Poll:     newLink("/polls/%d", pollId),
+		},
+		PollOption: option,
+	}
+}
+
+func newPollOptions(poll db.Poll) []pollOption {
+	options := make([]pollOption, 0, len(poll.PollOptions))
+	for _, option := range poll.PollOptions {
+		options = append(options, newPollOption(poll.PollId, option))
+	}
+	return options
+}
+
+func newPollResource(poll db.Poll) pollResource {
+	return pollResource{
+		Links: pollLinks{
+			selfLink:    selfLink{Self: newLink("/polls/%d", poll.PollId)},
+			PollOptions: newLink("/polls/%d/options", poll.PollId),
+		},
+		Embedded:     pollEmbedded{PollOptions: newPollOptions(poll)},
+		PollId:       poll.PollId,
+		PollTitle:    poll.PollTitle,
+		PollQuestion: poll.PollQuestion,
+	}
+}
+
+func newPollsResource(polls []db.Poll) pollsResource {
+	resources := make([]pollResource, 0, len(polls))
+	for _, poll := range polls {
+		resources = append(resources, newPollResource(poll))
+	}
+	return pollsResource{
+		Links:    selfLink{Self: newLink("/polls")},
+		Embedded: pollsEmbedded{Polls: resources},
+	}
+}
+
+func newPollOptionsResource(poll db.Poll) pollOptionsResource {
+	return pollOptionsResource{
+		Links: pollOptionsLinks{
+			selfLink: selfLink{Self: newLink("/polls/%d/options", poll.PollId)},
+			Poll:     newLink("/polls/%d", poll.PollId),
+		},
+		Embedded: pollEmbedded{PollOptions: newPollOptions(poll)},
+	}
+}
+
+// Votes
+
+type voteLinks struct {
+	selfLink
+	Voter     link `json:"voter"`
+	Poll      link `json:"poll"`
+	VoteValue link `json:"vote_value"`
+}
+
+type voteResource struct {
+	Links voteLinks `json:"_links"`
+	db.Vote
+}
+
+type votesEmbedded struct {
+	Votes []voteResource `json:"votes"`
+}
+
+type votesResource struct {
+	Links    selfLink      `json:"_links"`
+	Embedded votesEmbedded `json:"_embedded"`
+}
+
+func newVoteResource(vote db.Vote) voteResource {
+	return voteResource{
+		Links: voteLinks{
+			selfLink:  selfLink{Self: newLink("/votes/%d", vote.VoteId)},
+			Voter:     newLink("/voters/%d", vote.VoterId),
+			Poll:      newLink("/polls/%d", vote.PollId),
+			VoteValue: newLink("/polls/%d/options/%d", vote.PollId, vote.VoteValue),
+		},
+		Vote: vote,
+	}
+}
+
+func newVotesResource(votes []db.Vote) votesResource {
+	resources := make([]voteResource, 0, len(votes))
+	for _, vote := range votes {
+		resources = append(resources, newVoteResource(vote))
+	}
+	return votesResource{
+		Links:    selfLink{Self: newLink("/votes")},
+		Embedded: votesEmbedded{Votes: resources},
+	}
+}
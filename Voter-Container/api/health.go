@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// version and commit identify the build.  They are set when the api is
+// built, with -ldflags "-X drexel.edu/voter/api.version=... -X
+// drexel.edu/voter/api.commit=...", see the makefile and the Dockerfile.
+var (
+	version = "dev"
+	commit  = "unknown"
+)
+
+// readyTimeout is how long the readiness check waits for the store
+const readyTimeout = 2 * time.Second
+
+type dependency struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type health struct {
+	Status       string                `json:"status"`
+	Version      string                `json:"version"`
+	Commit       string                `json:"commit"`
+	Uptime       string                `json:"uptime"`
+	Dependencies map[string]dependency `json:"dependencies,omitempty"`
+}
+
+func (v *VoterAPI) newHealth(status string) health {
+	return health{
+		Status:  status,
+		Version: version,
+		Commit:  commit,
+		Uptime:  time.Since(v.bootTime).Round(time.Second).String(),
+	}
+}
+
+// implementation of GET /health/live.  The api is live as long as it can
+// answer, whatever state the store is in, so a failing liveness probe means
+// the process needs a restart.
+func (v *VoterAPI) Live(c *fiber.Ctx) error {
+	return c.Status(http.StatusOK).JSON(v.newHealth("ok"))
+}
+
+// Drain marks the api as shutting down.  From then on it is not ready,
+// so load balancers stop sending it requests while the ones in flight
+// finish.
+func (v *VoterAPI) Drain() {
+	v.draining.Store(true)
+}
+
+// implementation of GET /health/ready.  The api is ready when it can reach
+// its store, redis is pinged with a timeout so a hung redis fails the
+// check rather than the probe, and it is not draining.  A failed check is a
+// 503 Service Unavailable.
+func (v *VoterAPI) Ready(c *fiber.Ctx) error {
+	if v.draining.Load() {
+		return c.Status(http.StatusServiceUnavailable).JSON(v.newHealth("draining"))
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), readyTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := v.db.Ping(ctx)
+	store := dependency{Status: "up", Duration: time.Since(start).String()}
+
+	status := http.StatusOK
+	doc := v.newHealth("ready")
+	if err != nil {
+		store.Status = "down"
+		store.Error = err.Error()
+		status = http.StatusServiceUnavailable
+		doc.Status = "not ready"
+	}
+	doc.Dependencies = map[string]dependency{"store": store}
+
+	return c.Status(status).JSON(doc)
+}
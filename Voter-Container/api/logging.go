@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"drexel.edu/voter/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+const requestIdKey = "request_id"
+
+// LogRequests gives every request an id, the X-Request-ID header when the
+// client sent one, and logs the request once it is done with its status and
+// latency.  It has to come before the other handlers so their log lines
+// carry the id.  The id is sent back in the X-Request-ID response header and
+// stored in the request's user context, see logging.With.
+func LogRequests(c *fiber.Ctx) error {
+	start := time.Now()
+
+	id := utils.CopyString(c.Get(fiber.HeaderXRequestID))
+	if id == "" {
+		id = utils.UUIDv4()
+	}
+	c.Locals(requestIdKey, id)
+	c.Set(fiber.HeaderXRequestID, id)
+	c.SetUserContext(logging.With(c.UserContext(), requestIdKey, id))
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = newProblem(err).Status
+	}
+
+	// Probes and scrapes come every few seconds, they would drown out
+	// everything else
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	case strings.HasPrefix(c.Path(), "/health") || c.Path() == "/metrics":
+		level = slog.LevelDebug
+	}
+
+	// logger already has the request id, the user context would add it again
+	logger(c).LogAttrs(context.Background(), level, "Request",
+		slog.String("method", c.Method()),
+		slog.String("path", c.Path()),
+		slog.Int("status", status),
+		slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+	)
+	return err
+}
+
+// logger is the logger for a request.  Its lines carry the request id, the
+// route and, for the routes under /voters/:id, the voter id.
+func logger(c *fiber.Ctx) *slog.Logger {
+	route := c.Route().Path
+	args := []any{slog.String("route", route)}
+	if id, ok := c.Locals(requestIdKey).(string); ok {
+		args = append(args, slog.String(requestIdKey, id))
+	}
+	if strings.HasPrefix(route, "/voters/:id") {
+		if id, err := strconv.ParseUint(c.Params("id"), 10, 64); err == nil {
+			args = append(args, slog.Uint64("voter_id", id))
+		}
+	}
+	return slog.Default().With(args...)
+}
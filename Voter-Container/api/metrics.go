@@ -0,0 +1,110 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The api keeps its metrics in a registry of its own, rather than the
+// prometheus default one, so every VoterAPI starts from zero.  They are
+// served in the prometheus text format on /metrics.
+const metricsNamespace = "voter_api"
+
+type metrics struct {
+	registry *prometheus.Registry
+	handler  fiber.Handler
+
+	requests     *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	redisLatency *prometheus.HistogramVec
+	votesCast    *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Requests handled, by route and status code.",
+		}, []string{"method", "route", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "How long requests took to handle, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		redisLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "redis_command_duration_seconds",
+			Help:      "How long redis commands took, by command and whether they failed.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+		}, []string{"command", "result"}),
+		votesCast: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "votes_cast_total",
+			Help:      "Votes cast, by poll.",
+		}, []string{"poll"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.redisLatency,
+		m.votesCast,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	m.handler = adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return m
+}
+
+// observer is how the store tells the metrics about votes and redis
+func (m *metrics) observer() db.Observer {
+	return db.Observer{
+		VoteCast: func(vote db.VoterHistory) {
+			m.votesCast.WithLabelValues(strconv.FormatUint(uint64(vote.PollId), 10)).Inc()
+		},
+		RedisCommand: func(name string, took time.Duration, err error) {
+			result := "ok"
+			if err != nil {
+				result = "error"
+			}
+			m.redisLatency.WithLabelValues(name, result).Observe(took.Seconds())
+		},
+	}
+}
+
+// HandleStats counts and times every request it is in front of.  Requests
+// are labelled with the route they matched, such as /voters/:id, so the
+// number of series does not grow with the number of voters.
+func (v *VoterAPI) HandleStats(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = newProblem(err).Status
+	}
+	// fiber reuses the memory behind the method once the request is done,
+	// the labels outlive it
+	method := utils.CopyString(c.Method())
+	route := utils.CopyString(c.Route().Path)
+
+	v.metrics.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	v.metrics.latency.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// implementation of GET /metrics
+func (v *VoterAPI) Metrics(c *fiber.Ctx) error {
+	return v.metrics.handler(c)
+}
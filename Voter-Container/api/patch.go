@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PATCH requests carry a JSON Merge Patch (RFC 7396).  The patch is merged
+// into the stored resource and the result is validated and stored as if it
+// had been sent with a PUT.
+const mergePatchContentType = "application/merge-patch+json"
+
+// patchRetries is how many times a patch is reapplied when the voter
+// changed between reading it and storing the result.  Clients that sent
+// If-Match get the 412 instead, the others a 503 once the retries run out.
+const patchRetries = 3
+
+// mergePatch applies patch to target following RFC 7396: objects are
+// merged member by member, null removes a member and anything else
+// replaces the target
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
+
+// applyPatch merges the request body into original and decodes the result
+// into patched
+func applyPatch(c *fiber.Ctx, original interface{}, patched interface{}) error {
+	contentType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	contentType = strings.TrimSpace(contentType)
+	if contentType != mergePatchContentType && contentType != fiber.MIMEApplicationJSON {
+		return fiber.NewError(http.StatusUnsupportedMediaType,
+			"PATCH expects "+mergePatchContentType)
+	}
+
+	var patch interface{}
+	if err := json.Unmarshal(c.Body(), &patch); err != nil {
+		logger(c).Warn("Error binding JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	originalJson, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	var target interface{}
+	if err := json.Unmarshal(originalJson, &target); err != nil {
+		return err
+	}
+
+	mergedJson, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(mergedJson, patched); err != nil {
+		logger(c).Warn("Error binding patched JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+	return nil
+}
+
+// retryPatch runs patch until it does not fail because the voter changed
+// underneath it.  When the client sent If-Match there is no retry, the
+// change has to be against the version the client asked for.  A client
+// that sent no If-Match asked for no version, so losing every retry is
+// not a failed precondition but the voter being too busy.
+func retryPatch(c *fiber.Ctx, patch func(version uint64) error) error {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; ; i++ {
+		err := patch(version)
+		if version != db.AnyVersion || !errors.Is(err, db.ErrPreconditionFailed) {
+			return err
+		}
+		if i >= patchRetries {
+			return fmt.Errorf("%w: voter kept changing while it was patched", db.ErrBusy)
+		}
+	}
+}
+
+// implementation of PATCH /voters/:id
+func (v *VoterAPI) PatchVoter(c *fiber.Ctx) error {
+	var stored db.Voter
+	err := retryPatch(c, func(version uint64) error {
+		return v.withVoter(c, func(voter db.Voter) error {
+			var patched db.Voter
+			if err := applyPatch(c, voter, &patched); err != nil {
+				return err
+			}
+
+			if patched.VoterId != voter.VoterId {
+				logger(c).Warn("Patch cannot change voter_id")
+				return fiber.NewError(http.StatusBadRequest)
+			}
+
+			if err := patched.Validate(); err != nil {
+				logger(c).Warn("Voter is not valid", "error", err)
+				return err
+			}
+
+			// Without If-Match the patch is made against the version it
+			// was merged into
+			patched.Version = version
+			if version == db.AnyVersion {
+				patched.Version = voter.Version
+			}
+			var err error
+			stored, err = v.db.UpdateVoter(c.UserContext(), patched)
+			return err
+		})
+	})
+	if err != nil {
+		var e *fiber.Error
+		var invalid *db.ValidationError
+		if errors.As(err, &e) || errors.As(err, &invalid) {
+			return err
+		}
+		logger(c).Error("Error patching voter", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	c.Set(fiber.HeaderETag, voterETag(stored.Version))
+
+	return render(c, stored, func() interface{} { return newVoterResource(stored) })
+}
+
+// implementation of PATCH /voters/:id/polls/:pollid
+func (v *VoterAPI) PatchVoterPoll(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"pollid"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	var patched db.VoterHistory
+	var stored db.Vote
+	err := retryPatch(c, func(version uint64) error {
+		return v.withVoter(c, func(voter db.Voter) error {
+			existing, err := voter.GetVote(param.ID)
+			if err != nil {
+				logger(c).Warn("Error getting vote", "error", err)
+				return fiber.NewError(http.StatusNotFound)
+			}
+
+			patched = db.VoterHistory{}
+			if err := applyPatch(c, existing, &patched); err != nil {
+				return err
+			}
+
+			if patched.PollId != existing.PollId || patched.VoterId != existing.VoterId ||
+				patched.VoteId != existing.VoteId {
+				logger(c).Warn("Patch cannot change poll_id, voter_id or vote_id")
+				return fiber.NewError(http.StatusBadRequest)
+			}
+
+			merged, err := voter.UpdateVote(patched)
+			if err != nil {
+				return err
+			}
+			if err := merged.Validate(); err != nil {
+				logger(c).Warn("Voter is not valid", "error", err)
+				return err
+			}
+
+			vote := patched.ToVote()
+			vote.Version = version
+			if version == db.AnyVersion {
+				vote.Version = voter.Version
+			}
+			stored, err = v.db.UpdateVote(c.UserContext(), vote)
+			return err
+		})
+	})
+	if err != nil {
+		var e *fiber.Error
+		var invalid *db.ValidationError
+		if errors.As(err, &e) || errors.As(err, &invalid) {
+			return err
+		}
+		logger(c).Error("Error patching vote", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	c.Set(fiber.HeaderETag, voterETag(stored.Version))
+
+	return render(c, patched, func() interface{} { return newVoterPoll(patched) })
+}
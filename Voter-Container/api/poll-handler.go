@@ -0,0 +1,222 @@
+package api
+
+import (
+	"net/http"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (v *VoterAPI) ListAllPolls(c *fiber.Ctx) error {
+	pollList, err := v.db.GetAllPolls(c.UserContext())
+	if err != nil {
+		logger(c).Error("Error getting all polls", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
+			"Error Getting All Polls")
+	}
+	if pollList == nil {
+		pollList = make([]db.Poll, 0)
+	}
+	return render(c, pollList, func() interface{} { return newPollsResource(pollList) })
+}
+
+func (v *VoterAPI) DeleteAllPolls(c *fiber.Ctx) error {
+	if err := v.db.DeleteAllPolls(c.UserContext()); err != nil {
+		logger(c).Error("Error deleting all polls", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
+			"Error Deleting All Polls")
+	}
+
+	return c.Status(http.StatusOK).SendString("Delete OK")
+}
+
+func (v *VoterAPI) withPoll(c *fiber.Ctx, run func(poll db.Poll) error) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	err := c.ParamsParser(&param)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	poll, err := v.db.GetPoll(c.UserContext(), param.ID)
+	if err != nil {
+		logger(c).Warn("Poll not found", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound))
+	}
+
+	return run(poll)
+}
+
+func (v *VoterAPI) GetPoll(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		return render(c, poll, func() interface{} { return newPollResource(poll) })
+	})
+}
+
+func (v *VoterAPI) AddPoll(c *fiber.Ctx) error {
+	var poll db.Poll
+
+	if err := c.BodyParser(&poll); err != nil {
+		logger(c).Warn("Error binding JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if err := poll.ValidateOptions(); err != nil {
+		logger(c).Warn("Poll is not valid")
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if err := v.db.AddPoll(c.UserContext(), poll); err != nil {
+		logger(c).Error("Error adding poll", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	return render(c, poll, func() interface{} { return newPollResource(poll) })
+}
+
+func (v *VoterAPI) UpdatePoll(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	var poll db.Poll
+
+	if err := c.BodyParser(&poll); err != nil {
+		logger(c).Warn("Error binding JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if poll.PollId != param.ID {
+		logger(c).Warn("Poll does not match id parameter")
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if err := poll.ValidateOptions(); err != nil {
+		logger(c).Warn("Poll is not valid")
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if err := v.db.UpdatePoll(c.UserContext(), poll); err != nil {
+		logger(c).Error("Error updating poll", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	return render(c, poll, func() interface{} { return newPollResource(poll) })
+}
+
+func (v *VoterAPI) DeletePoll(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if err := v.db.DeletePoll(c.UserContext(), param.ID); err != nil {
+		logger(c).Error("Error deleting poll", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	return c.Status(http.StatusOK).SendString("Delete OK")
+}
+
+func (v *VoterAPI) GetAllOptions(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		return render(c, poll.PollOptions, func() interface{} { return newPollOptionsResource(poll) })
+	})
+}
+
+func (v *VoterAPI) AddOption(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		var option db.PollOption
+
+		if err := c.BodyParser(&option); err != nil {
+			logger(c).Warn("Error binding JSON", "error", err)
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		if err := v.db.AddOption(c.UserContext(), poll.PollId, option); err != nil {
+			logger(c).Error("Error adding option to poll", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+		}
+
+		return render(c, option, func() interface{} { return newPollOption(poll.PollId, option) })
+	})
+}
+
+func (v *VoterAPI) GetOption(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		param := struct {
+			ID uint `params:"optionid"`
+		}{}
+
+		if err := c.ParamsParser(&param); err != nil {
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		option, err := poll.GetOption(param.ID)
+		if err != nil {
+			logger(c).Warn("Error getting option", "error", err)
+			return fiber.NewError(http.StatusNotFound)
+		}
+
+		return render(c, option, func() interface{} { return newPollOption(poll.PollId, option) })
+	})
+}
+
+func (v *VoterAPI) UpdateOption(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		param := struct {
+			ID uint `params:"optionid"`
+		}{}
+
+		if err := c.ParamsParser(&param); err != nil {
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		var option db.PollOption
+
+		if err := c.BodyParser(&option); err != nil {
+			logger(c).Warn("Error binding JSON", "error", err)
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		if option.PollOptionId != param.ID {
+			logger(c).Warn("Option Id does not match optionid parameter")
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		if err := v.db.UpdateOption(c.UserContext(), poll.PollId, option); err != nil {
+			logger(c).Error("Error updating option", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+		}
+
+		return render(c, option, func() interface{} { return newPollOption(poll.PollId, option) })
+	})
+}
+
+func (v *VoterAPI) DeleteOption(c *fiber.Ctx) error {
+	return v.withPoll(c, func(poll db.Poll) error {
+		param := struct {
+			ID uint `params:"optionid"`
+		}{}
+
+		if err := c.ParamsParser(&param); err != nil {
+			return fiber.NewError(http.StatusBadRequest)
+		}
+
+		if err := v.db.DeleteOption(c.UserContext(), poll.PollId, param.ID); err != nil {
+			logger(c).Error("Error deleting option", "error", err)
+			return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+		}
+
+		return c.Status(http.StatusOK).SendString("Delete OK")
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Errors are reported as Problem Details (RFC 7807).  Handlers return
+// fiber errors, or a *db.ValidationError when the request body failed
+// validation, and ErrorHandler turns them into a problem document.
+const problemContentType = "application/problem+json"
+
+type invalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []invalidParam `json:"invalid-params,omitempty"`
+}
+
+// newProblem describes err as a problem.  Anything that is not a fiber
+// error or a validation error is an internal error.
+func newProblem(err error) problem {
+	p := problem{Type: "about:blank", Status: http.StatusInternalServerError}
+
+	var fiberError *fiber.Error
+	var validationError *db.ValidationError
+	switch {
+	case errors.As(err, &validationError):
+		p.Status = http.StatusBadRequest
+		p.Detail = "The request failed validation."
+		for _, field := range validationError.Fields {
+			p.InvalidParams = append(p.InvalidParams, invalidParam{Name: field.Field, Reason: field.Reason})
+		}
+	case errors.As(err, &fiberError):
+		p.Status = fiberError.Code
+		if fiberError.Message != http.StatusText(fiberError.Code) {
+			p.Detail = fiberError.Message
+		}
+	}
+
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// ErrorHandler is the fiber error handler for the api, it sends every
+// error as an application/problem+json document
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	p := newProblem(err)
+	p.Instance = c.OriginalURL()
+	if p.Status == http.StatusInternalServerError {
+		logger(c).Error("Internal error", "error", err)
+	}
+
+	return c.Status(p.Status).JSON(p, problemContentType)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/http"
+
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+)
+
+func (v *VoterAPI) ListAllVotes(c *fiber.Ctx) error {
+	voteList, err := v.db.GetAllVotes(c.UserContext())
+	if err != nil {
+		logger(c).Error("Error getting all votes", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
+			"Error Getting All Votes")
+	}
+	if voteList == nil {
+		voteList = make([]db.Vote, 0)
+	}
+	return render(c, voteList, func() interface{} { return newVotesResource(voteList) })
+}
+
+func (v *VoterAPI) DeleteAllVotes(c *fiber.Ctx) error {
+	if err := v.db.DeleteAllVotes(c.UserContext()); err != nil {
+		logger(c).Error("Error deleting all votes", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound),
+			"Error Deleting All Votes")
+	}
+
+	return c.Status(http.StatusOK).SendString("Delete OK")
+}
+
+func (v *VoterAPI) GetVote(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	vote, err := v.db.GetVote(c.UserContext(), param.ID)
+	if err != nil {
+		logger(c).Warn("Vote not found", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusNotFound))
+	}
+
+	if notModified(c, voterETag(vote.Version)) {
+		return nil
+	}
+	return render(c, vote, func() interface{} { return newVoteResource(vote) })
+}
+
+func (v *VoterAPI) AddVote(c *fiber.Ctx) error {
+	var vote db.Vote
+
+	if err := c.BodyParser(&vote); err != nil {
+		logger(c).Warn("Error binding JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	history := vote.ToHistory()
+	if err := history.Validate(); err != nil {
+		logger(c).Warn("Vote is not valid", "error", err)
+		return err
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	vote.Version = version
+
+	vote, err = v.db.AddVote(c.UserContext(), vote)
+	if err != nil {
+		logger(c).Error("Error adding vote", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+	c.Set(fiber.HeaderETag, voterETag(vote.Version))
+
+	return render(c, vote, func() interface{} { return newVoteResource(vote) })
+}
+
+func (v *VoterAPI) UpdateVote(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	var vote db.Vote
+
+	if err := c.BodyParser(&vote); err != nil {
+		logger(c).Warn("Error binding JSON", "error", err)
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	if vote.VoteId != param.ID {
+		logger(c).Warn("Vote does not match id parameter")
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	history := vote.ToHistory()
+	if err := history.Validate(); err != nil {
+		logger(c).Warn("Vote is not valid", "error", err)
+		return err
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	vote.Version = version
+
+	vote, err = v.db.UpdateVote(c.UserContext(), vote)
+	if err != nil {
+		logger(c).Error("Error updating vote", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+	c.Set(fiber.HeaderETag, voterETag(vote.Version))
+
+	return render(c, vote, func() interface{} { return newVoteResource(vote) })
+}
+
+func (v *VoterAPI) DeleteVote(c *fiber.Ctx) error {
+	param := struct {
+		ID uint `params:"id"`
+	}{}
+
+	if err := c.ParamsParser(&param); err != nil {
+		return fiber.NewError(http.StatusBadRequest)
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := v.db.DeleteVote(c.UserContext(), param.ID, version); err != nil {
+		logger(c).Error("Error deleting vote", "error", err)
+		return fiber.NewError(statusFor(err, http.StatusInternalServerError))
+	}
+
+	return c.Status(http.StatusOK).SendString("Delete OK")
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisConfig is where the redis store finds redis and how it logs in.
+// The fields other than URL override what the URL says when they are set.
+type RedisConfig struct {
+	// URL is redis://[username:password@]host:port[/db], or rediss:// for
+	// TLS.  A bare host:port is taken as a redis:// URL.  The query
+	// parameters go-redis knows, like dial_timeout or pool_size, are
+	// honoured too.
+	URL string
+
+	Username string
+	Password string
+
+	// DB is the database index, -1 keeps the one in the URL
+	DB int
+
+	// TLS turns TLS on for a redis:// URL.  CACertFile is a PEM file of
+	// the certificates redis's certificate is checked against, in place
+	// of the system's.
+	TLS        bool
+	CACertFile string
+
+	// SentinelMaster is the name Sentinel knows the master by.  Setting
+	// it connects through the Sentinels at SentinelAddrs, or at the
+	// URL's host when there are none, which follow the master as it
+	// fails over.
+	SentinelMaster   string
+	SentinelAddrs    []string
+	SentinelUsername string
+	SentinelPassword string
+}
+
+// NewRedisClient connects to redis as the config says, through Sentinel if
+// it names a master.  Connecting is lazy, nothing is sent to redis yet.
+func NewRedisClient(config RedisConfig) (*redis.Client, error) {
+	opts, err := config.options()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.SentinelMaster == "" {
+		return redis.NewClient(opts), nil
+	}
+
+	addrs := config.SentinelAddrs
+	if len(addrs) == 0 {
+		addrs = []string{opts.Addr}
+	}
+
+	// The master is not at the URL's host, so its certificate is checked
+	// against the name of whichever host Sentinel points at
+	tlsConfig := opts.TLSConfig
+	if tlsConfig != nil {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = ""
+	}
+
+	return redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:            config.SentinelMaster,
+		SentinelAddrs:         addrs,
+		SentinelUsername:      config.SentinelUsername,
+		SentinelPassword:      config.SentinelPassword,
+		ClientName:            opts.ClientName,
+		Protocol:              opts.Protocol,
+		Username:              opts.Username,
+		Password:              opts.Password,
+		DB:                    opts.DB,
+		MaxRetries:            opts.MaxRetries,
+		MinRetryBackoff:       opts.MinRetryBackoff,
+		MaxRetryBackoff:       opts.MaxRetryBackoff,
+		DialTimeout:           opts.DialTimeout,
+		ReadTimeout:           opts.ReadTimeout,
+		WriteTimeout:          opts.WriteTimeout,
+		ContextTimeoutEnabled: opts.ContextTimeoutEnabled,
+		PoolFIFO:              opts.PoolFIFO,
+		PoolSize:              opts.PoolSize,
+		PoolTimeout:           opts.PoolTimeout,
+		MinIdleConns:          opts.MinIdleConns,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxActiveConns:        opts.MaxActiveConns,
+		ConnMaxIdleTime:       opts.ConnMaxIdleTime,
+		ConnMaxLifetime:       opts.ConnMaxLifetime,
+		TLSConfig:             tlsConfig,
+	}), nil
+}
+
+// options turns the config into the options of a single redis client
+func (config RedisConfig) options() (*redis.Options, error) {
+	location := config.URL
+	if location == "" {
+		location = RedisDefaultLocation
+	}
+	if !strings.Contains(location, "://") {
+		location = "redis://" + location
+	}
+
+	opts, err := redis.ParseURL(location)
+	if err != nil {
+		// The URL may hold a password, so it is left out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	if config.Username != "" {
+		opts.Username = config.Username
+	}
+	if config.Password != "" {
+		opts.Password = config.Password
+	}
+	if config.DB >= 0 {
+		opts.DB = config.DB
+	}
+
+	if config.TLS && opts.TLSConfig == nil {
+		// The server name is taken from the address when connecting
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if config.CACertFile != "" {
+		if opts.TLSConfig == nil {
+			return nil, errors.New("a redis CA certificate needs TLS, use a rediss:// url or turn TLS on")
+		}
+		pool, err := loadCACerts(config.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig.RootCAs = pool
+	}
+
+	// Without it the deadlines of the contexts only apply to waiting
+	// for a connection, not to the commands sent on it
+	opts.ContextTimeoutEnabled = true
+
+	return opts, nil
+}
+
+func loadCACerts(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading the redis CA certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
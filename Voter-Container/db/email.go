@@ -0,0 +1,22 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Every voter must have an email of its own.  The stores keep an index of
+// emails to voter ids, so duplicates are found without reading every voter,
+// and voters can be looked up by email.
+
+// emailKey is how an email is stored in the index.  Emails that only differ
+// in case or surrounding spaces are the same email.
+func emailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// duplicateEmail is the error for a voter trying to take an email that owner
+// already has.  It wraps ErrConflict.
+func duplicateEmail(voter Voter, owner uint) error {
+	return fmt.Errorf("%w: email %q is already used by voter %d", ErrConflict, voter.Email, owner)
+}
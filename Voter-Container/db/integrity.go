@@ -0,0 +1,48 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeletePolicy controls what happens to the votes that refer to a voter,
+// poll or poll option when it is deleted
+type DeletePolicy int
+
+const (
+	// DeleteCascade removes the votes along with the voter, poll or option
+	DeleteCascade DeletePolicy = iota
+	// DeleteRestrict refuses to delete anything that votes still refer to
+	DeleteRestrict
+)
+
+// ErrConflict is returned when a change would leave a vote referring to
+// something that does not exist, or give a voter an email another voter
+// already has.  It is wrapped with the details of the conflict so use
+// errors.Is to check for it.
+var ErrConflict = errors.New("conflict")
+
+func ParseDeletePolicy(policy string) (DeletePolicy, error) {
+	switch policy {
+	case "cascade":
+		return DeleteCascade, nil
+	case "restrict":
+		return DeleteRestrict, nil
+	}
+	return DeleteCascade, fmt.Errorf("unknown delete policy %q", policy)
+}
+
+func (p DeletePolicy) String() string {
+	if p == DeleteRestrict {
+		return "restrict"
+	}
+	return "cascade"
+}
+
+// checkVote makes sure the vote is for one of the poll's options
+func (p *Poll) checkVote(vote VoterHistory) error {
+	if _, err := p.GetOption(vote.VoteValue); err != nil {
+		return fmt.Errorf("%w: poll %d has no option %d", ErrConflict, p.PollId, vote.VoteValue)
+	}
+	return nil
+}
@@ -0,0 +1,678 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryVoterList struct {
+	voters     map[uint]Voter  //A map of VoterIDs as keys and Voter structs as values
+	polls      map[uint]Poll   //A map of PollIDs as keys and Poll structs as values
+	votes      map[uint]uint   //A map of VoteIDs as keys and the owning VoterID as values
+	emails     map[string]uint //A map of emails, see emailKey, as keys and the owning VoterID as values
+	lastVoteId uint
+
+	// mu guards the maps above.  Changes to the store are serialized, so
+	// concurrent votes for the same voter cannot overwrite each other,
+	// while reads can run side by side.
+	mu sync.RWMutex
+
+	deletePolicy DeletePolicy
+	observer     Observer
+}
+
+// constructor for MemoryVoterList struct, an in-memory VoterStore that is
+// handy for development and testing when redis is not available.  It never
+// waits on anything, so it ignores the contexts it is given.
+func NewMemoryVoterList(deletePolicy DeletePolicy) (*MemoryVoterList, error) {
+	voterList := &MemoryVoterList{
+		voters:       make(map[uint]Voter),
+		polls:        make(map[uint]Poll),
+		votes:        make(map[uint]uint),
+		emails:       make(map[string]uint),
+		deletePolicy: deletePolicy,
+	}
+	return voterList, nil
+}
+
+func (v *MemoryVoterList) GetAllVoters(ctx context.Context) ([]Voter, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var voters []Voter
+
+	for _, voter := range v.voters {
+		voters = append(voters, voter)
+	}
+
+	return voters, nil
+}
+
+func (v *MemoryVoterList) GetVoterPage(ctx context.Context, query VoterQuery) (VoterPage, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	voters := make([]Voter, 0, len(v.voters))
+	for id, voter := range v.voters {
+		if query.ByCursor() && id < query.Cursor {
+			continue
+		}
+		if query.matches(voter) {
+			voters = append(voters, voter)
+		}
+	}
+	query.sortVoters(voters)
+
+	if !query.ByCursor() {
+		voters = voters[min(query.Offset, len(voters)):]
+	}
+
+	var page VoterPage
+	if len(voters) > query.Limit {
+		page.More = true
+		page.Next = query.next(voters[query.Limit].VoterId)
+		voters = voters[:query.Limit]
+	}
+	page.Voters = voters
+
+	return page, nil
+}
+
+func (v *MemoryVoterList) AddVoter(ctx context.Context, voter Voter) (Voter, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, ok := v.voters[voter.VoterId]
+	if ok {
+		return Voter{}, errors.New("Voter already exists.")
+	}
+
+	if err := v.checkEmail(voter); err != nil {
+		return Voter{}, err
+	}
+
+	if err := v.checkVoteReferences(voter.VoteHistory); err != nil {
+		return Voter{}, err
+	}
+
+	if err := v.indexVotes(&voter, Voter{}); err != nil {
+		return Voter{}, err
+	}
+
+	return v.putVoter(voter, Voter{}), nil
+}
+
+// putVoter stores the voter as the next version after previous, moving
+// its entry in the email index if the email changed.  The stored voter is
+// returned.
+func (v *MemoryVoterList) putVoter(voter Voter, previous Voter) Voter {
+	v.unindexEmail(previous)
+	v.emails[emailKey(voter.Email)] = voter.VoterId
+
+	voter.Version = previous.Version + 1
+	v.voters[voter.VoterId] = voter
+
+	v.observer.votesCast(voter, previous)
+	return voter
+}
+
+func (v *MemoryVoterList) Observe(observer Observer) {
+	v.observer = observer
+}
+
+// Ping always succeeds, there is nothing to reach
+func (v *MemoryVoterList) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close has nothing to let go of, the voters are lost with the process
+func (v *MemoryVoterList) Close() error {
+	return nil
+}
+
+// checkEmail makes sure no other voter has the voter's email
+func (v *MemoryVoterList) checkEmail(voter Voter) error {
+	owner, ok := v.emails[emailKey(voter.Email)]
+	if ok && owner != voter.VoterId {
+		return duplicateEmail(voter, owner)
+	}
+	return nil
+}
+
+// unindexEmail removes the voter's email from the email index, as long as
+// the index still has it as the voter's
+func (v *MemoryVoterList) unindexEmail(voter Voter) {
+	key := emailKey(voter.Email)
+	if owner, ok := v.emails[key]; ok && owner == voter.VoterId {
+		delete(v.emails, key)
+	}
+}
+
+func (v *MemoryVoterList) GetVoter(ctx context.Context, id uint) (Voter, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	voter, ok := v.voters[id]
+	if !ok {
+		return Voter{}, errors.New("No voter for id.")
+	}
+
+	return voter, nil
+}
+
+func (v *MemoryVoterList) GetVoterByEmail(ctx context.Context, email string) (Voter, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	id, ok := v.emails[emailKey(email)]
+	if !ok {
+		return Voter{}, errors.New("No voter for email.")
+	}
+
+	return v.voters[id], nil
+}
+
+// SearchVoters scans every voter, which is fine for the amount of data the
+// in-memory store is meant for
+func (v *MemoryVoterList) SearchVoters(ctx context.Context, query string, limit int) ([]Voter, error) {
+	terms, err := searchTerms(query)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var ids []uint
+	for id, voter := range v.voters {
+		if matchesSearch(voter, terms) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	voters := make([]Voter, 0, len(ids))
+	for _, id := range ids {
+		voters = append(voters, v.voters[id])
+	}
+	return voters, nil
+}
+
+func (v *MemoryVoterList) DeleteVoter(ctx context.Context, id uint, version uint64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	voter, ok := v.voters[id]
+
+	if !ok {
+		return errors.New("No voter for id.")
+	}
+
+	if err := checkVersion(voter, version); err != nil {
+		return err
+	}
+
+	if v.deletePolicy == DeleteRestrict && len(voter.VoteHistory) > 0 {
+		return fmt.Errorf("%w: voter %d has votes", ErrConflict, id)
+	}
+
+	for _, vote := range voter.VoteHistory {
+		delete(v.votes, vote.VoteId)
+	}
+	v.unindexEmail(voter)
+	delete(v.voters, id)
+
+	return nil
+}
+
+func (v *MemoryVoterList) DeleteAll(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.deletePolicy == DeleteRestrict && len(v.votes) > 0 {
+		return fmt.Errorf("%w: voters have votes", ErrConflict)
+	}
+
+	v.voters = make(map[uint]Voter)
+	v.votes = make(map[uint]uint)
+	v.emails = make(map[string]uint)
+	return nil
+}
+
+func (v *MemoryVoterList) UpdateVoter(ctx context.Context, voter Voter) (Voter, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	previous, ok := v.voters[voter.VoterId]
+	if !ok {
+		return Voter{}, errors.New("Voter does not exist.")
+	}
+
+	if err := checkVersion(previous, voter.Version); err != nil {
+		return Voter{}, err
+	}
+
+	if err := v.checkEmail(voter); err != nil {
+		return Voter{}, err
+	}
+
+	if err := v.checkVoteReferences(voter.VoteHistory); err != nil {
+		return Voter{}, err
+	}
+
+	if err := v.indexVotes(&voter, previous); err != nil {
+		return Voter{}, err
+	}
+
+	return v.putVoter(voter, previous), nil
+}
+
+// indexVotes gives every vote in the voter's history an id and records
+// it in the vote index.  Votes that were already in the previous version
+// of the voter keep their id, and votes that have been dropped are
+// removed from the index.
+func (v *MemoryVoterList) indexVotes(voter *Voter, previous Voter) error {
+	voter.carryVoteIds(previous)
+	if err := voter.ValidateVotes(); err != nil {
+		return err
+	}
+
+	for _, vote := range voter.VoteHistory {
+		owner, ok := v.votes[vote.VoteId]
+		if vote.VoteId != 0 && ok && owner != voter.VoterId {
+			return errors.New("Vote id belongs to another voter.")
+		}
+	}
+
+	// The ids later votes in the history already have are not free
+	reserved := voter.voteIds()
+	for i := range voter.VoteHistory {
+		if voter.VoteHistory[i].VoteId == 0 {
+			voter.VoteHistory[i].VoteId = v.nextVoteId(reserved)
+			reserved[voter.VoteHistory[i].VoteId] = struct{}{}
+		}
+	}
+	if err := voter.ValidateVotes(); err != nil {
+		return err
+	}
+
+	for _, vote := range previous.VoteHistory {
+		delete(v.votes, vote.VoteId)
+	}
+	for _, vote := range voter.VoteHistory {
+		v.votes[vote.VoteId] = voter.VoterId
+	}
+
+	return nil
+}
+
+// nextVoteId returns the next id that is neither in the vote index nor
+// reserved
+func (v *MemoryVoterList) nextVoteId(reserved map[uint]struct{}) uint {
+	for {
+		v.lastVoteId++
+		_, used := v.votes[v.lastVoteId]
+		_, taken := reserved[v.lastVoteId]
+		if !used && !taken {
+			return v.lastVoteId
+		}
+	}
+}
+
+func (v *MemoryVoterList) GetAllPolls(ctx context.Context) ([]Poll, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var polls []Poll
+
+	for _, poll := range v.polls {
+		polls = append(polls, poll)
+	}
+
+	return polls, nil
+}
+
+func (v *MemoryVoterList) AddPoll(ctx context.Context, poll Poll) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, ok := v.polls[poll.PollId]
+	if ok {
+		return fmt.Errorf("%w: poll %d already exists", ErrConflict, poll.PollId)
+	}
+
+	v.polls[poll.PollId] = poll
+
+	return nil
+}
+
+func (v *MemoryVoterList) GetPoll(ctx context.Context, id uint) (Poll, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	poll, ok := v.polls[id]
+	if !ok {
+		return Poll{}, errors.New("No poll for id.")
+	}
+
+	return poll, nil
+}
+
+func (v *MemoryVoterList) DeletePoll(ctx context.Context, id uint) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	_, ok := v.polls[id]
+
+	if !ok {
+		return errors.New("No poll for id.")
+	}
+
+	err := v.removeVotes(func(vote VoterHistory) bool {
+		return vote.PollId == id
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(v.polls, id)
+
+	return nil
+}
+
+func (v *MemoryVoterList) DeleteAllPolls(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	err := v.removeVotes(func(vote VoterHistory) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	v.polls = make(map[uint]Poll)
+	return nil
+}
+
+// UpdatePoll replaces the poll.  Votes for options that are no longer
+// part of the poll are handled according to the delete policy.
+func (v *MemoryVoterList) UpdatePoll(ctx context.Context, poll Poll) error {
+	return v.changePoll(poll.PollId, func(Poll) (Poll, error) {
+		return poll, nil
+	})
+}
+
+func (v *MemoryVoterList) AddOption(ctx context.Context, pollId uint, option PollOption) error {
+	return v.changePoll(pollId, func(poll Poll) (Poll, error) {
+		return poll.AddOption(option)
+	})
+}
+
+func (v *MemoryVoterList) UpdateOption(ctx context.Context, pollId uint, option PollOption) error {
+	return v.changePoll(pollId, func(poll Poll) (Poll, error) {
+		return poll.UpdateOption(option)
+	})
+}
+
+func (v *MemoryVoterList) DeleteOption(ctx context.Context, pollId uint, optionId uint) error {
+	return v.changePoll(pollId, func(poll Poll) (Poll, error) {
+		return poll.DeleteOption(optionId)
+	})
+}
+
+// changePoll replaces the poll with what change makes of it while holding
+// the lock, so nothing changes the poll in between.  Votes for options
+// that are no longer part of the poll are handled according to the delete
+// policy.
+func (v *MemoryVoterList) changePoll(id uint, change func(poll Poll) (Poll, error)) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	current, ok := v.polls[id]
+	if !ok {
+		return errors.New("Poll does not exist.")
+	}
+
+	poll, err := change(current)
+	if err != nil {
+		return err
+	}
+
+	err = v.removeVotes(func(vote VoterHistory) bool {
+		return vote.PollId == poll.PollId && poll.checkVote(vote) != nil
+	})
+	if err != nil {
+		return err
+	}
+
+	v.polls[poll.PollId] = poll
+
+	return nil
+}
+
+func (v *MemoryVoterList) GetAllVotes(ctx context.Context) ([]Vote, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var votes []Vote
+
+	for _, voter := range v.voters {
+		for _, vote := range voter.VoteHistory {
+			votes = append(votes, vote.ToVote())
+		}
+	}
+
+	return votes, nil
+}
+
+func (v *MemoryVoterList) GetVote(ctx context.Context, id uint) (Vote, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	voter, err := v.voterForVote(id)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	history, err := voter.getVoteById(id)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	vote := history.ToVote()
+	vote.Version = voter.Version
+	return vote, nil
+}
+
+// AddVote records a vote against the voter that cast it.  If the vote
+// does not have an id the next free id is assigned.  The stored vote
+// is returned.
+func (v *MemoryVoterList) AddVote(ctx context.Context, vote Vote) (Vote, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	voter, ok := v.voters[vote.VoterId]
+	if !ok {
+		return Vote{}, errors.New("No voter for id.")
+	}
+
+	if err := checkVersion(voter, vote.Version); err != nil {
+		return Vote{}, err
+	}
+
+	if err := v.checkVoteReferences([]VoterHistory{vote.ToHistory()}); err != nil {
+		return Vote{}, err
+	}
+
+	if _, ok := v.votes[vote.VoteId]; ok {
+		return Vote{}, errors.New("Vote id already exists.")
+	}
+
+	if vote.VoteId == 0 {
+		vote.VoteId = v.nextVoteId(nil)
+	}
+	if vote.VoteDate.IsZero() {
+		vote.VoteDate = time.Now()
+	}
+
+	updated, err := voter.AddVote(vote.ToHistory())
+	if err != nil {
+		return Vote{}, err
+	}
+
+	v.putVoter(updated, voter)
+	v.votes[vote.VoteId] = voter.VoterId
+
+	vote.Version = v.voters[voter.VoterId].Version
+	return vote, nil
+}
+
+func (v *MemoryVoterList) UpdateVote(ctx context.Context, vote Vote) (Vote, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	voter, err := v.voterForVote(vote.VoteId)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	if voter.VoterId != vote.VoterId {
+		return Vote{}, errors.New("Vote cannot be moved to another voter.")
+	}
+
+	if err := checkVersion(voter, vote.Version); err != nil {
+		return Vote{}, err
+	}
+
+	if err := v.checkVoteReferences([]VoterHistory{vote.ToHistory()}); err != nil {
+		return Vote{}, err
+	}
+
+	updated, err := voter.replaceVote(vote.ToHistory())
+	if err != nil {
+		return Vote{}, err
+	}
+
+	v.putVoter(updated, voter)
+
+	vote.Version = v.voters[voter.VoterId].Version
+	return vote, nil
+}
+
+func (v *MemoryVoterList) DeleteVote(ctx context.Context, id uint, version uint64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	voter, err := v.voterForVote(id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(voter, version); err != nil {
+		return err
+	}
+
+	vote, err := voter.getVoteById(id)
+	if err != nil {
+		return err
+	}
+
+	updated, err := voter.DeleteVote(vote.PollId)
+	if err != nil {
+		return err
+	}
+
+	v.putVoter(updated, voter)
+	delete(v.votes, id)
+
+	return nil
+}
+
+func (v *MemoryVoterList) DeleteAllVotes(ctx context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for _, voter := range v.voters {
+		if len(voter.VoteHistory) == 0 {
+			continue
+		}
+
+		updated := voter
+		updated.VoteHistory = make([]VoterHistory, 0)
+		v.putVoter(updated, voter)
+	}
+	v.votes = make(map[uint]uint)
+	return nil
+}
+
+func (v *MemoryVoterList) voterForVote(id uint) (Voter, error) {
+	voterId, ok := v.votes[id]
+	if !ok {
+		return Voter{}, errors.New("No vote for id.")
+	}
+
+	voter, ok := v.voters[voterId]
+	if !ok {
+		return Voter{}, errors.New("No voter for id.")
+	}
+	return voter, nil
+}
+
+// checkVoteReferences makes sure every vote is for an option of a poll
+// that exists
+func (v *MemoryVoterList) checkVoteReferences(votes []VoterHistory) error {
+	for _, vote := range votes {
+		poll, ok := v.polls[vote.PollId]
+		if !ok {
+			return fmt.Errorf("%w: poll %d does not exist", ErrConflict, vote.PollId)
+		}
+		if err := poll.checkVote(vote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeVotes applies the delete policy to the votes that match.  With
+// DeleteRestrict nothing is removed and ErrConflict is returned if any
+// vote matches.
+func (v *MemoryVoterList) removeVotes(match func(vote VoterHistory) bool) error {
+	if v.deletePolicy == DeleteRestrict {
+		for _, voter := range v.voters {
+			for _, vote := range voter.VoteHistory {
+				if match(vote) {
+					return fmt.Errorf("%w: vote %d would be removed", ErrConflict, vote.VoteId)
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, voter := range v.voters {
+		history := make([]VoterHistory, 0, len(voter.VoteHistory))
+		for _, vote := range voter.VoteHistory {
+			if match(vote) {
+				delete(v.votes, vote.VoteId)
+				continue
+			}
+			history = append(history, vote)
+		}
+		if len(history) == len(voter.VoteHistory) {
+			continue
+		}
+
+		updated := voter
+		updated.VoteHistory = history
+		v.putVoter(updated, voter)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Observer is told what a store does, so the api can keep metrics without
+// the db package depending on them.  Funcs that are nil are skipped.
+type Observer struct {
+	// VoteCast is called for every new vote once it is stored
+	VoteCast func(vote VoterHistory)
+
+	// RedisCommand is called after every command sent to redis, pipelines
+	// and transactions are reported as a whole.  A redis nil reply is not
+	// an error.
+	RedisCommand func(name string, took time.Duration, err error)
+}
+
+// votesCast reports the votes in voter that were not in previous, which
+// is the version of the voter it replaced
+func (o Observer) votesCast(voter Voter, previous Voter) {
+	if o.VoteCast == nil {
+		return
+	}
+	for _, vote := range voter.VoteHistory {
+		if _, err := previous.GetVote(vote.PollId); err != nil {
+			o.VoteCast(vote)
+		}
+	}
+}
+
+func (o Observer) redisCommand(name string, took time.Duration, err error) {
+	if o.RedisCommand == nil {
+		return
+	}
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	o.RedisCommand(name, took, err)
+}
+
+// redisHook times the commands the store sends to redis for its Observer.
+// It also wraps the errors of commands that timed out or could not reach
+// redis, see storeError, so callers can tell them from other failures.
+type redisHook struct {
+	list *VoterList
+}
+
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := storeError(next(ctx, cmd))
+		h.list.observer.redisCommand(cmd.Name(), time.Since(start), err)
+		return err
+	}
+}
+
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		name := "pipeline"
+		if len(cmds) > 0 && cmds[0].Name() == "multi" {
+			name = "multi"
+		}
+
+		start := time.Now()
+		err := storeError(next(ctx, cmds))
+		if unreachable(err) {
+			// Every command in the pipeline failed the same way
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+		}
+		h.list.observer.redisCommand(name, time.Since(start), err)
+		return err
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+type PollOption struct {
+	PollOptionId   uint   `json:"poll_option_id"`
+	PollOptionText string `json:"poll_option_text"`
+}
+
+type Poll struct {
+	PollId       uint         `json:"poll_id"`
+	PollTitle    string       `json:"poll_title"`
+	PollQuestion string       `json:"poll_question"`
+	PollOptions  []PollOption `json:"poll_options"`
+}
+
+func (p *Poll) GetOption(optionId uint) (PollOption, error) {
+	for _, option := range p.PollOptions {
+		if option.PollOptionId == optionId {
+			return option, nil
+		}
+	}
+	return PollOption{}, errors.New("option does not exist")
+}
+
+func (p Poll) AddOption(newOption PollOption) (Poll, error) {
+	for _, option := range p.PollOptions {
+		if option.PollOptionId == newOption.PollOptionId {
+			return Poll{}, fmt.Errorf("%w: option %d already exists", ErrConflict, newOption.PollOptionId)
+		}
+	}
+
+	options := make([]PollOption, len(p.PollOptions), len(p.PollOptions)+1)
+	copy(options, p.PollOptions)
+	p.PollOptions = append(options, newOption)
+	return p, nil
+}
+
+func (p Poll) UpdateOption(updatedOption PollOption) (Poll, error) {
+	var foundOption bool
+	var optionIndex int
+	for i, option := range p.PollOptions {
+		if option.PollOptionId == updatedOption.PollOptionId {
+			foundOption = true
+			optionIndex = i
+			break
+		}
+	}
+
+	if !foundOption {
+		return Poll{}, errors.New("option does not exist")
+	}
+
+	options := make([]PollOption, len(p.PollOptions))
+	copy(options, p.PollOptions)
+	options[optionIndex] = updatedOption
+	p.PollOptions = options
+
+	return p, nil
+}
+
+func (p Poll) DeleteOption(optionId uint) (Poll, error) {
+	var foundOption bool
+	var optionIndex int
+	for i, option := range p.PollOptions {
+		if option.PollOptionId == optionId {
+			foundOption = true
+			optionIndex = i
+			break
+		}
+	}
+
+	if !foundOption {
+		return Poll{}, errors.New("option does not exist")
+	}
+
+	options := make([]PollOption, 0, len(p.PollOptions)-1)
+	options = append(options, p.PollOptions[:optionIndex]...)
+	p.PollOptions = append(options, p.PollOptions[optionIndex+1:]...)
+	return p, nil
+}
+
+func (p *Poll) ValidateOptions() error {
+	var seen = make(map[uint]struct{})
+	for _, option := range p.PollOptions {
+		_, ok := seen[option.PollOptionId]
+		if ok {
+			return errors.New("duplicate option found")
+		}
+		seen[option.PollOptionId] = struct{}{}
+	}
+
+	return nil
+}
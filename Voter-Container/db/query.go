@@ -0,0 +1,147 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VoterQuery picks the voters for a page of GET /voters and the order they
+// come in.  The filters are applied by the stores, so the redis store can
+// answer them from its search index rather than read every voter.
+//
+// Pages in voter id order, the default, start at the voter id Cursor and so
+// stay put while voters come and go.  Any other order is paged by Offset.
+type VoterQuery struct {
+	Sort VoterSort
+
+	// VotedIn only keeps the voters that voted in the poll
+	VotedIn *uint
+	// VotedAfter only keeps the voters with a vote cast after the time
+	VotedAfter *time.Time
+
+	Cursor uint
+	Offset int
+	Limit  int
+}
+
+// The fields voters can be sorted by
+const (
+	SortByVoterId = "voter_id"
+	SortByName    = "name"
+	SortByEmail   = "email"
+)
+
+// MaxVoterOffset is the largest offset voters can be paged by.  Every page
+// is sorted along with all the voters before it, so deeper pages are
+// refused and the voters have to be narrowed with a filter instead.  It is
+// the default MAXSEARCHRESULTS of RediSearch.
+const MaxVoterOffset = 10000
+
+// VoterSort orders voters by one of their fields.  Names and emails are
+// compared without case.  The zero VoterSort is voter id order.
+type VoterSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseVoterSort reads a sort like name or -email, a leading - sorts in
+// descending order.  Leaving it empty sorts by voter id.  A bad sort is a
+// *ValidationError.
+func ParseVoterSort(text string) (VoterSort, error) {
+	field, desc := strings.CutPrefix(text, "-")
+	switch field {
+	case "":
+		return VoterSort{}, nil
+	case SortByVoterId, SortByName, SortByEmail:
+		return VoterSort{Field: field, Desc: desc}, nil
+	}
+	return VoterSort{}, &ValidationError{Fields: []FieldError{{
+		Field:  "sort",
+		Reason: fmt.Sprintf("must be one of %s, %s or %s, optionally starting with -", SortByVoterId, SortByName, SortByEmail),
+	}}}
+}
+
+func (s VoterSort) String() string {
+	if s.Desc {
+		return "-" + s.field()
+	}
+	return s.field()
+}
+
+func (s VoterSort) field() string {
+	if s.Field == "" {
+		return SortByVoterId
+	}
+	return s.Field
+}
+
+// ByCursor tells whether the query is paged by voter id cursor rather than
+// by offset
+func (q VoterQuery) ByCursor() bool {
+	return q.Sort.field() == SortByVoterId && !q.Sort.Desc
+}
+
+// next is the cursor or offset of the page after this one, which starts
+// with the voter id first
+func (q VoterQuery) next(first uint) uint {
+	if q.ByCursor() {
+		return first
+	}
+	return uint(q.Offset + q.Limit)
+}
+
+// Filtered tells whether the query leaves out any voters
+func (q VoterQuery) Filtered() bool {
+	return q.VotedIn != nil || q.VotedAfter != nil
+}
+
+// matches tells whether the voter passes the query's filters
+func (q VoterQuery) matches(voter Voter) bool {
+	if q.VotedIn != nil {
+		if _, err := voter.GetVote(*q.VotedIn); err != nil {
+			return false
+		}
+	}
+	if q.VotedAfter != nil && !lastVoteAt(voter).After(*q.VotedAfter) {
+		return false
+	}
+	return true
+}
+
+// sortVoters puts the voters in the query's order, voters that sort the
+// same are kept in voter id order
+func (q VoterQuery) sortVoters(voters []Voter) {
+	key := func(voter Voter) string {
+		switch q.Sort.Field {
+		case SortByName:
+			return strings.ToLower(voter.Name)
+		case SortByEmail:
+			return strings.ToLower(voter.Email)
+		}
+		return ""
+	}
+
+	sort.Slice(voters, func(i, j int) bool {
+		a, b := key(voters[i]), key(voters[j])
+		if a != b {
+			return (a < b) != q.Sort.Desc
+		}
+		if q.Sort.field() == SortByVoterId {
+			return (voters[i].VoterId < voters[j].VoterId) != q.Sort.Desc
+		}
+		return voters[i].VoterId < voters[j].VoterId
+	})
+}
+
+// lastVoteAt is when the voter last voted, the zero time if it never did
+func lastVoteAt(voter Voter) time.Time {
+	var last time.Time
+	for _, vote := range voter.VoteHistory {
+		if vote.VoteDate.After(last) {
+			last = vote.VoteDate
+		}
+	}
+	return last
+}
@@ -0,0 +1,1578 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RedisNilError        = "redis: nil"
+	RedisDefaultLocation = "0.0.0.0:6379"
+	RedisKeyPrefix       = "voter:"
+	RedisPollKeyPrefix   = "poll:"
+	RedisVoteIndexKey    = "vote-index"
+	RedisPollVotesPrefix = "poll-votes:"
+	RedisPollVotesKey    = "poll-votes-indexed"
+	RedisVoteIdKey       = "vote-id"
+	RedisVoterIdsKey     = "voter-ids"
+	RedisEmailIndexKey   = "voter-emails"
+	RedisLastVotesKey    = "voter-last-votes"
+	RedisSearchIndex     = "voter-search"
+	RedisSearchSchemaKey = "voter-search-schema"
+
+	// redisBatchSize is how many keys are asked for in each SCAN and
+	// JSON.MGET, so no single command blocks redis for long
+	redisBatchSize = 1000
+
+	// redisTxRetries is how many times a transaction on a voter is retried
+	// when another client changed the voter first
+	redisTxRetries = 100
+
+	// redisSearchSchema is the version of the search index schema, which
+	// is stored under RedisSearchSchemaKey.  It has to go up whenever
+	// createSearchIndex changes the schema, so the old index is replaced.
+	redisSearchSchema = 2
+)
+
+var (
+	errVoterBusy = fmt.Errorf("%w: voter is being updated by too many clients, try again", ErrBusy)
+	errPollBusy  = fmt.Errorf("%w: poll is being voted on by too many clients, try again", ErrBusy)
+	errNoPoll    = errors.New("no poll for id")
+	errNoVote    = errors.New("no vote for id")
+)
+
+type cache struct {
+	client *redis.Client
+}
+
+type VoterList struct {
+	cache
+	deletePolicy DeletePolicy
+	timeouts     Timeouts
+	observer     Observer
+}
+
+// constructor for VoterList struct, connecting to redis as the config says
+func NewVoterList(config RedisConfig, deletePolicy DeletePolicy, timeouts Timeouts) (*VoterList, error) {
+	client, err := NewRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return newVoterList(client, deletePolicy, timeouts)
+}
+
+// NewWithCacheInstance connects to the redis instance at location, a
+// host:port or a URL, see RedisConfig
+func NewWithCacheInstance(location string, deletePolicy DeletePolicy, timeouts Timeouts) (*VoterList, error) {
+	return NewVoterList(RedisConfig{URL: location, DB: -1}, deletePolicy, timeouts)
+}
+
+func newVoterList(client *redis.Client, deletePolicy DeletePolicy, timeouts Timeouts) (*VoterList, error) {
+	voterList := &VoterList{
+		cache: cache{
+			client: client,
+		},
+		deletePolicy: deletePolicy,
+		timeouts:     timeouts,
+	}
+	client.AddHook(redisHook{list: voterList})
+
+	// Startup is not tied to a request, it gets the bulk timeout
+	ctx, cancel := voterList.bulk(context.Background())
+	defer cancel()
+
+	if err := voterList.Ping(ctx); err != nil {
+		slog.Error("Error connecting to redis", "error", err)
+		return nil, err
+	}
+
+	if err := voterList.indexVoters(ctx); err != nil {
+		slog.Error("Error indexing voters", "error", err)
+		return nil, err
+	}
+
+	if err := voterList.indexEmails(ctx); err != nil {
+		slog.Error("Error indexing emails", "error", err)
+		return nil, err
+	}
+
+	if err := voterList.indexPollVotes(ctx); err != nil {
+		slog.Error("Error indexing the votes of each poll", "error", err)
+		return nil, err
+	}
+
+	if err := voterList.indexLastVotes(ctx); err != nil {
+		slog.Error("Error indexing the last votes", "error", err)
+		return nil, err
+	}
+
+	if err := voterList.createSearchIndex(ctx); err != nil {
+		slog.Error("Error creating the search index", "error", err)
+		return nil, err
+	}
+
+	return voterList, nil
+}
+
+// func isRedisNilError(err error) bool {
+// 	return errors.Is(err, redis.Nil) || err.Error() == RedisNilError
+// }
+
+func redisKeyFromId(id uint) string {
+	return fmt.Sprintf("%s%d", RedisKeyPrefix, id)
+}
+
+// getAllKeys walks the keys with SCAN rather than KEYS, so redis keeps
+// serving other clients while a large key space is listed
+func (v *VoterList) getAllKeys(ctx context.Context, prefix string) ([]string, error) {
+	match := fmt.Sprintf("%s*", prefix)
+
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := v.client.Scan(ctx, cursor, match, redisBatchSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+
+		cursor = next
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+func fromJsonString(s string, item any) error {
+	err := json.Unmarshal([]byte(s), &item)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// redisVoter is how a voter is stored in redis.  The version is kept in the
+// document even though it is not part of the json the api sends for a voter.
+// So is the time of the voter's last vote, in unix milliseconds, which the
+// search index needs as a number to filter on.
+type redisVoter struct {
+	Voter
+	Version    uint64 `json:"version"`
+	LastVoteAt *int64 `json:"last_vote_at,omitempty"`
+}
+
+func voterFromJsonString(s string) (Voter, error) {
+	var item redisVoter
+	if err := json.Unmarshal([]byte(s), &item); err != nil {
+		return Voter{}, err
+	}
+
+	voter := item.Voter
+	voter.Version = item.Version
+	return voter, nil
+}
+
+func (v *VoterList) upsertVoter(ctx context.Context, pipe redis.Cmdable, item *Voter) error {
+	slog.DebugContext(ctx, "Storing voter", "key", redisKeyFromId(item.VoterId))
+	stored := redisVoter{Voter: *item, Version: item.Version}
+	if len(item.VoteHistory) > 0 {
+		last := lastVoteAt(*item).UnixMilli()
+		stored.LastVoteAt = &last
+	}
+	return pipe.JSONSet(ctx, redisKeyFromId(item.VoterId), ".", stored).Err()
+}
+
+// watchVoter runs fn with the voter key WATCHed, so the writes fn makes in
+// a MULTI/EXEC only happen if nobody else changed the voter since fn read
+// it.  The transaction is retried when it loses the race.  exists tells fn
+// whether the voter was found.
+func (v *VoterList) watchVoter(ctx context.Context, id uint, fn func(tx *redis.Tx, voter Voter, exists bool) error) error {
+	key := redisKeyFromId(id)
+	txf := func(tx *redis.Tx) error {
+		itemJson, err := tx.JSONGet(ctx, key, ".").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		if itemJson == "" {
+			return fn(tx, Voter{}, false)
+		}
+		voter, err := voterFromJsonString(itemJson)
+		if err != nil {
+			return err
+		}
+		return fn(tx, voter, true)
+	}
+
+	for i := 0; i < redisTxRetries; i++ {
+		err := v.client.Watch(ctx, txf, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return errVoterBusy
+}
+
+// updateVoter atomically replaces the voter with the result of update,
+// as its next version, and keeps the vote index in step with it.  update
+// is given the transaction so it can WATCH what else it reads.  The stored
+// voter is returned.
+func (v *VoterList) updateVoter(ctx context.Context, id uint, update func(tx *redis.Tx, voter Voter) (Voter, error)) (Voter, error) {
+	var stored Voter
+	err := v.watchVoter(ctx, id, func(tx *redis.Tx, voter Voter, exists bool) error {
+		if !exists {
+			return errors.New("no voter for id")
+		}
+
+		updated, err := update(tx, voter)
+		if err != nil {
+			return err
+		}
+		updated.Version = voter.Version + 1
+
+		if emailKey(updated.Email) != emailKey(voter.Email) {
+			if err := v.claimEmail(ctx, tx, updated); err != nil {
+				return err
+			}
+		}
+
+		if err := v.checkVoteReferences(ctx, tx, changedVotes(updated, voter)); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := v.upsertVoter(ctx, pipe, &updated); err != nil {
+				return err
+			}
+			v.indexEmail(ctx, pipe, updated, voter)
+			return v.indexVotes(ctx, pipe, updated, voter)
+		})
+		if err == nil {
+			v.observer.votesCast(updated, voter)
+		}
+		stored = updated
+		return err
+	})
+	return stored, err
+}
+
+// read, write and bulk bound ctx by the store's timeout for the kind of
+// operation, see Timeouts
+func (v *VoterList) read(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, v.timeouts.Read)
+}
+
+func (v *VoterList) write(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, v.timeouts.Write)
+}
+
+func (v *VoterList) bulk(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, v.timeouts.Bulk)
+}
+
+func (v *VoterList) Observe(observer Observer) {
+	v.observer = observer
+}
+
+func (v *VoterList) Ping(ctx context.Context) error {
+	return v.client.Ping(ctx).Err()
+}
+
+func (v *VoterList) Close() error {
+	return v.client.Close()
+}
+
+// Helper to return a Voter or Poll from redis provided a key
+func (v *VoterList) getItemFromRedis(ctx context.Context, key string, item any) error {
+	itemJson, err := v.client.JSONGet(ctx, key, ".").Result()
+	if err != nil {
+		return err
+	}
+
+	return fromJsonString(itemJson, item)
+}
+
+// getItemsFromRedis fetches the JSON documents for keys with JSON.MGET,
+// batching the keys.  Keys that no longer exist are skipped.
+func (v *VoterList) getItemsFromRedis(ctx context.Context, keys []string) ([]string, error) {
+	items := make([]string, 0, len(keys))
+	for start := 0; start < len(keys); start += redisBatchSize {
+		end := start + redisBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		batch, err := v.client.JSONMGet(ctx, ".", keys[start:end]...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range batch {
+			if itemJson, ok := item.(string); ok && itemJson != "" {
+				items = append(items, itemJson)
+			}
+		}
+	}
+	return items, nil
+}
+
+func (v *VoterList) doesKeyExist(ctx context.Context, key string) (bool, error) {
+	kc, err := v.client.Exists(ctx, key).Result()
+	return kc > 0, err
+}
+
+func (v *VoterList) GetAllVoters(ctx context.Context) ([]Voter, error) {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	keyList, err := v.getAllKeys(ctx, RedisKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return v.getVoters(ctx, keyList)
+}
+
+func (v *VoterList) getVoters(ctx context.Context, keyList []string) ([]Voter, error) {
+	items, err := v.getItemsFromRedis(ctx, keyList)
+	if err != nil {
+		return nil, err
+	}
+
+	voters := make([]Voter, len(items))
+	for idx, item := range items {
+		if voters[idx], err = voterFromJsonString(item); err != nil {
+			return nil, err
+		}
+	}
+
+	return voters, nil
+}
+
+// GetVoterPage answers queries for every voter in id order from the sorted
+// set of voter ids, anything else from the search index
+func (v *VoterList) GetVoterPage(ctx context.Context, query VoterQuery) (VoterPage, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	if query.ByCursor() && !query.Filtered() {
+		return v.getVoterIdPage(ctx, query.Cursor, query.Limit)
+	}
+	return v.searchVoterPage(ctx, query)
+}
+
+// getVoterIdPage returns up to limit voters, in id order, starting with the
+// voter id cursor.  The voter ids are kept in a sorted set so a page only
+// touches the voters on it.
+func (v *VoterList) getVoterIdPage(ctx context.Context, cursor uint, limit int) (VoterPage, error) {
+	ids, err := v.client.ZRangeByScore(ctx, RedisVoterIdsKey, &redis.ZRangeBy{
+		Min:   strconv.FormatUint(uint64(cursor), 10),
+		Max:   "+inf",
+		Count: int64(limit) + 1,
+	}).Result()
+	if err != nil {
+		return VoterPage{}, err
+	}
+
+	var page VoterPage
+	if len(ids) > limit {
+		next, err := strconv.ParseUint(ids[limit], 10, 64)
+		if err != nil {
+			return VoterPage{}, err
+		}
+		page.Next = uint(next)
+		page.More = true
+		ids = ids[:limit]
+	}
+
+	keyList := make([]string, len(ids))
+	for idx, id := range ids {
+		keyList[idx] = RedisKeyPrefix + id
+	}
+
+	page.Voters, err = v.getVoters(ctx, keyList)
+	if err != nil {
+		return VoterPage{}, err
+	}
+	return page, nil
+}
+
+// indexVoters builds the sorted set of voter ids used for paging if it is
+// missing, for example when the voters were stored by an older version
+func (v *VoterList) indexVoters(ctx context.Context) error {
+	if exists, err := v.doesKeyExist(ctx, RedisVoterIdsKey); exists || err != nil {
+		return err
+	}
+
+	keyList, err := v.getAllKeys(ctx, RedisKeyPrefix)
+	if err != nil {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(keyList))
+	for _, key := range keyList {
+		id, err := strconv.ParseUint(key[len(RedisKeyPrefix):], 10, 64)
+		if err != nil {
+			continue
+		}
+		members = append(members, redis.Z{Score: float64(id), Member: id})
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return v.client.ZAdd(ctx, RedisVoterIdsKey, members...).Err()
+}
+
+// indexEmails builds the email index if it is missing, for example when the
+// voters were stored by an older version.  Should two stored voters share an
+// email, the first one found keeps it.
+func (v *VoterList) indexEmails(ctx context.Context) error {
+	if exists, err := v.doesKeyExist(ctx, RedisEmailIndexKey); exists || err != nil {
+		return err
+	}
+
+	voters, err := v.GetAllVoters(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = v.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, voter := range voters {
+			pipe.HSetNX(ctx, RedisEmailIndexKey, emailKey(voter.Email), voter.VoterId)
+		}
+		return nil
+	})
+	return err
+}
+
+// indexLastVotes stores the time of the last vote in the voters with votes
+// that were stored by an older version without it, so voted_after finds
+// them.  RedisLastVotesKey is set once it is done, so it only runs once.
+func (v *VoterList) indexLastVotes(ctx context.Context) error {
+	if exists, err := v.doesKeyExist(ctx, RedisLastVotesKey); exists || err != nil {
+		return err
+	}
+
+	keyList, err := v.getAllKeys(ctx, RedisKeyPrefix)
+	if err != nil {
+		return err
+	}
+	items, err := v.getItemsFromRedis(ctx, keyList)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var stored redisVoter
+		if err := fromJsonString(item, &stored); err != nil {
+			return err
+		}
+		if stored.LastVoteAt != nil || len(stored.VoteHistory) == 0 {
+			continue
+		}
+
+		// The voter is written again as it is, which stores the time, in
+		// case it changed since it was read
+		err := v.watchVoter(ctx, stored.VoterId, func(tx *redis.Tx, voter Voter, exists bool) error {
+			if !exists {
+				return nil
+			}
+			_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				return v.upsertVoter(ctx, pipe, &voter)
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return v.client.Set(ctx, RedisLastVotesKey, 1, 0).Err()
+}
+
+// claimEmail WATCHes the email index and makes sure no other voter has the
+// voter's email, so two voters cannot take the same email at once.  It has
+// to be called inside the voter's transaction, before the writes.
+func (v *VoterList) claimEmail(ctx context.Context, tx *redis.Tx, voter Voter) error {
+	if err := tx.Watch(ctx, RedisEmailIndexKey).Err(); err != nil {
+		return err
+	}
+
+	owner, err := tx.HGet(ctx, RedisEmailIndexKey, emailKey(voter.Email)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if uint(owner) != voter.VoterId {
+		return duplicateEmail(voter, uint(owner))
+	}
+	return nil
+}
+
+// indexEmail moves the voter's entry in the email index from the email of
+// the previous version to its current email.  An empty voter removes the
+// previous email.
+func (v *VoterList) indexEmail(ctx context.Context, pipe redis.Cmdable, voter Voter, previous Voter) {
+	key := emailKey(voter.Email)
+	if previous.VoterId != 0 && emailKey(previous.Email) != key {
+		pipe.HDel(ctx, RedisEmailIndexKey, emailKey(previous.Email))
+	}
+	if voter.VoterId != 0 && (previous.VoterId == 0 || emailKey(previous.Email) != key) {
+		pipe.HSet(ctx, RedisEmailIndexKey, key, voter.VoterId)
+	}
+}
+
+func (v *VoterList) AddVoter(ctx context.Context, voter Voter) (Voter, error) {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	err := v.watchVoter(ctx, voter.VoterId, func(tx *redis.Tx, _ Voter, exists bool) error {
+		if exists {
+			return fmt.Errorf("Voter with id %d already exists", voter.VoterId)
+		}
+
+		if err := v.claimEmail(ctx, tx, voter); err != nil {
+			return err
+		}
+
+		if err := v.checkVoteReferences(ctx, tx, voter.VoteHistory); err != nil {
+			return err
+		}
+
+		if err := v.assignVoteIds(ctx, tx, &voter, Voter{}); err != nil {
+			return err
+		}
+		voter.Version = 1
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if err := v.upsertVoter(ctx, pipe, &voter); err != nil {
+				return err
+			}
+			v.indexEmail(ctx, pipe, voter, Voter{})
+			pipe.ZAdd(ctx, RedisVoterIdsKey, redis.Z{
+				Score:  float64(voter.VoterId),
+				Member: voter.VoterId,
+			})
+			return v.indexVotes(ctx, pipe, voter, Voter{})
+		})
+		if err == nil {
+			v.observer.votesCast(voter, Voter{})
+		}
+		return err
+	})
+	if err != nil {
+		return Voter{}, err
+	}
+	return voter, nil
+}
+
+func (v *VoterList) GetVoter(ctx context.Context, id uint) (Voter, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	itemJson, err := v.client.JSONGet(ctx, redisKeyFromId(id), ".").Result()
+	if err != nil {
+		return Voter{}, err
+	}
+	if itemJson == "" {
+		return Voter{}, errors.New("no voter for id")
+	}
+	return voterFromJsonString(itemJson)
+}
+
+func (v *VoterList) GetVoterByEmail(ctx context.Context, email string) (Voter, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	id, err := v.client.HGet(ctx, RedisEmailIndexKey, emailKey(email)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return Voter{}, errors.New("no voter for email")
+	}
+	if err != nil {
+		return Voter{}, err
+	}
+	return v.GetVoter(ctx, uint(id))
+}
+
+// createSearchIndex creates the RediSearch index over the voter documents
+// that SearchVoters and GetVoterPage use, unless it is already there.  Redis
+// keeps the index up to date as voters are written, so nothing else has to
+// maintain it.  An index made with an older schema, going by
+// RedisSearchSchemaKey, is dropped and made again.
+func (v *VoterList) createSearchIndex(ctx context.Context) error {
+	schema, err := v.client.Get(ctx, RedisSearchSchemaKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	// An index from a newer version is left to it
+	if schema > redisSearchSchema {
+		return nil
+	}
+	if schema < redisSearchSchema {
+		if err := v.dropSearchIndexes(ctx); err != nil {
+			return err
+		}
+	}
+
+	err = v.client.Do(ctx, "FT.CREATE", RedisSearchIndex, "ON", "JSON",
+		"PREFIX", "1", RedisKeyPrefix, "STOPWORDS", "0",
+		"SCHEMA",
+		"$.voter_id", "AS", "voter_id", "NUMERIC", "SORTABLE",
+		"$.name", "AS", "name", "TEXT", "NOSTEM", "SORTABLE",
+		"$.email", "AS", "email", "TEXT", "NOSTEM", "SORTABLE",
+		"$.voter_history[*].poll_id", "AS", "poll_id", "NUMERIC",
+		"$.last_vote_at", "AS", "last_vote_at", "NUMERIC").Err()
+	if err != nil && !strings.Contains(err.Error(), "Index already exists") {
+		return err
+	}
+	return v.client.Set(ctx, RedisSearchSchemaKey, redisSearchSchema, 0).Err()
+}
+
+// dropSearchIndexes drops the voter search index, along with any named
+// after it by the builds that put the schema version in the name.
+// Dropping an index leaves the voters alone.
+func (v *VoterList) dropSearchIndexes(ctx context.Context) error {
+	names, err := v.client.Do(ctx, "FT._LIST").StringSlice()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if name != RedisSearchIndex && !strings.HasPrefix(name, RedisSearchIndex+"-") {
+			continue
+		}
+		err := v.client.Do(ctx, "FT.DROPINDEX", name).Err()
+		if err != nil && !strings.Contains(strings.ToLower(err.Error()), "unknown index") {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchVoterKeys runs an FT.AGGREGATE on the voter index and returns the
+// keys of the voters found, in the order the sort puts them.  Voters that
+// sort the same are kept in voter id order, which FT.SEARCH can't do as it
+// only sorts by one field, so pages by offset neither skip nor repeat them.
+func (v *VoterList) searchVoterKeys(ctx context.Context, query string, sort VoterSort, offset int, limit int) ([]string, error) {
+	order := "ASC"
+	if sort.Desc {
+		order = "DESC"
+	}
+	sortBy := []interface{}{"SORTBY", 2, "@" + sort.field(), order}
+	if sort.field() != SortByVoterId {
+		sortBy = []interface{}{"SORTBY", 4, "@" + sort.field(), order, "@voter_id", "ASC"}
+	}
+
+	args := []interface{}{"FT.AGGREGATE", RedisSearchIndex, query, "LOAD", 1, "@__key"}
+	args = append(args, sortBy...)
+	args = append(args, "MAX", offset+limit, "LIMIT", offset, limit, "DIALECT", 2)
+	reply, err := v.client.Do(ctx, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return aggregateResultKeys(reply)
+}
+
+// SearchVoters asks the search index for the keys of the matching voters
+// and then reads them like any other list of voters
+func (v *VoterList) SearchVoters(ctx context.Context, query string, limit int) ([]Voter, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	terms, err := searchTerms(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The terms only have letters, digits and underscores, so none of
+	// them need escaping
+	clauses := make([]string, len(terms))
+	for i, term := range terms {
+		clauses[i] = fmt.Sprintf("@name|email:%s*", term)
+	}
+
+	keys, err := v.searchVoterKeys(ctx, strings.Join(clauses, " "), VoterSort{}, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	return v.getVoters(ctx, keys)
+}
+
+// searchVoterPage turns the query's filters into a search of the voter
+// index, so only the voters on the page are read
+func (v *VoterList) searchVoterPage(ctx context.Context, query VoterQuery) (VoterPage, error) {
+	var clauses []string
+	if query.VotedIn != nil {
+		clauses = append(clauses, fmt.Sprintf("@poll_id:[%d %d]", *query.VotedIn, *query.VotedIn))
+	}
+	if query.VotedAfter != nil {
+		clauses = append(clauses, fmt.Sprintf("@last_vote_at:[(%d +inf]", query.VotedAfter.UnixMilli()))
+	}
+
+	offset := query.Offset
+	if query.ByCursor() {
+		clauses = append(clauses, fmt.Sprintf("@voter_id:[%d +inf]", query.Cursor))
+		offset = 0
+	}
+
+	search := "*"
+	if len(clauses) > 0 {
+		search = strings.Join(clauses, " ")
+	}
+
+	keys, err := v.searchVoterKeys(ctx, search, query.Sort, offset, query.Limit+1)
+	if err != nil {
+		return VoterPage{}, err
+	}
+
+	var page VoterPage
+	if len(keys) > query.Limit {
+		first, err := strconv.ParseUint(strings.TrimPrefix(keys[query.Limit], RedisKeyPrefix), 10, 64)
+		if err != nil {
+			return VoterPage{}, err
+		}
+		page.Next = query.next(uint(first))
+		page.More = true
+		keys = keys[:query.Limit]
+	}
+
+	page.Voters, err = v.getVoters(ctx, keys)
+	if err != nil {
+		return VoterPage{}, err
+	}
+	return page, nil
+}
+
+// aggregateResultKeys pulls the document keys out of an FT.AGGREGATE reply
+// that loaded @__key.  With RESP2 it is an array of the number of results
+// followed by a field, value array for each, with RESP3 it is a map.
+func aggregateResultKeys(reply interface{}) ([]string, error) {
+	var keys []string
+	switch reply := reply.(type) {
+	case []interface{}:
+		for _, row := range reply[min(1, len(reply)):] {
+			fields, _ := row.([]interface{})
+			for i := 0; i+1 < len(fields); i += 2 {
+				if fields[i] == "__key" {
+					if key, ok := fields[i+1].(string); ok {
+						keys = append(keys, key)
+					}
+				}
+			}
+		}
+	case map[interface{}]interface{}:
+		results, _ := reply["results"].([]interface{})
+		for _, result := range results {
+			row, _ := result.(map[interface{}]interface{})
+			fields, _ := row["extra_attributes"].(map[interface{}]interface{})
+			if key, ok := fields["__key"].(string); ok {
+				keys = append(keys, key)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unexpected FT.AGGREGATE reply %T", reply)
+	}
+	return keys, nil
+}
+
+func (v *VoterList) DeleteVoter(ctx context.Context, id uint, version uint64) error {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	return v.watchVoter(ctx, id, func(tx *redis.Tx, voter Voter, exists bool) error {
+		if !exists {
+			return errors.New("no voter for id")
+		}
+
+		if err := checkVersion(voter, version); err != nil {
+			return err
+		}
+
+		if v.deletePolicy == DeleteRestrict && len(voter.VoteHistory) > 0 {
+			return fmt.Errorf("%w: voter %d has votes", ErrConflict, id)
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, redisKeyFromId(id))
+			pipe.ZRem(ctx, RedisVoterIdsKey, id)
+			v.indexEmail(ctx, pipe, Voter{}, voter)
+			return v.indexVotes(ctx, pipe, Voter{}, voter)
+		})
+		return err
+	})
+}
+
+func (v *VoterList) DeleteAll(ctx context.Context) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	if v.deletePolicy == DeleteRestrict {
+		votes, err := v.client.HLen(ctx, RedisVoteIndexKey).Result()
+		if err != nil {
+			return err
+		}
+		if votes > 0 {
+			return fmt.Errorf("%w: voters have votes", ErrConflict)
+		}
+	}
+
+	keyList, err := v.getAllKeys(ctx, RedisKeyPrefix)
+	if err != nil {
+		return err
+	}
+	pollVotes, err := v.getAllKeys(ctx, RedisPollVotesPrefix)
+	if err != nil {
+		return err
+	}
+	keyList = append(keyList, pollVotes...)
+	keyList = append(keyList, RedisVoteIndexKey, RedisVoterIdsKey, RedisEmailIndexKey)
+	for start := 0; start < len(keyList); start += redisBatchSize {
+		end := start + redisBatchSize
+		if end > len(keyList) {
+			end = len(keyList)
+		}
+		if err := v.client.Del(ctx, keyList[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *VoterList) UpdateVoter(ctx context.Context, voter Voter) (Voter, error) {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	return v.updateVoter(ctx, voter.VoterId, func(tx *redis.Tx, previous Voter) (Voter, error) {
+		if err := checkVersion(previous, voter.Version); err != nil {
+			return Voter{}, err
+		}
+
+		updated := voter
+		updated.VoteHistory = append([]VoterHistory(nil), voter.VoteHistory...)
+		if err := v.assignVoteIds(ctx, tx, &updated, previous); err != nil {
+			return Voter{}, err
+		}
+		return updated, nil
+	})
+}
+
+// assignVoteIds gives every vote in the voter's history an id.  Votes
+// that were already in the previous version of the voter keep their id,
+// and the ids the history brings that it didn't have before are claimed
+// in tx.
+func (v *VoterList) assignVoteIds(ctx context.Context, tx *redis.Tx, voter *Voter, previous Voter) error {
+	voter.carryVoteIds(previous)
+	if err := voter.ValidateVotes(); err != nil {
+		return err
+	}
+
+	// The ids later votes in the history already have are not free
+	reserved := voter.voteIds()
+	kept := previous.voteIds()
+	var claimed []uint
+	for i, vote := range voter.VoteHistory {
+		if vote.VoteId != 0 {
+			if _, ok := kept[vote.VoteId]; !ok {
+				claimed = append(claimed, vote.VoteId)
+			}
+			continue
+		}
+
+		id, err := v.nextVoteId(ctx, reserved)
+		if err != nil {
+			return err
+		}
+		voter.VoteHistory[i].VoteId = id
+		reserved[id] = struct{}{}
+	}
+
+	if err := v.claimVoteIds(ctx, tx, voter.VoterId, claimed); err != nil {
+		return err
+	}
+	return voter.ValidateVotes()
+}
+
+// claimVoteIds checks that no other voter has the vote ids.  The vote index
+// is WATCHed while they are read, so a vote given one of them by another
+// voter before tx commits makes it start over.  Ids nobody has are free,
+// an error reading the index does not make them so.
+func (v *VoterList) claimVoteIds(ctx context.Context, tx *redis.Tx, voterId uint, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Watch(ctx, RedisVoteIndexKey).Err(); err != nil {
+		return err
+	}
+
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	owners, err := tx.HMGet(ctx, RedisVoteIndexKey, fields...).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, owner := range owners {
+		if owner == nil {
+			continue
+		}
+		id, err := strconv.ParseUint(fmt.Sprint(owner), 10, 64)
+		if err != nil {
+			return err
+		}
+		if uint(id) != voterId {
+			return errors.New("vote id belongs to another voter")
+		}
+	}
+	return nil
+}
+
+// indexVotes records the owner of each of the voter's votes in the vote
+// index, removing the votes that were dropped from the previous version
+func (v *VoterList) indexVotes(ctx context.Context, pipe redis.Cmdable, voter Voter, previous Voter) error {
+	var dropped []string
+	for _, vote := range previous.VoteHistory {
+		if _, err := voter.getVoteById(vote.VoteId); err != nil {
+			dropped = append(dropped, strconv.FormatUint(uint64(vote.VoteId), 10))
+		}
+	}
+	if len(dropped) > 0 {
+		if err := pipe.HDel(ctx, RedisVoteIndexKey, dropped...).Err(); err != nil {
+			return err
+		}
+	}
+
+	if len(voter.VoteHistory) == 0 {
+		return nil
+	}
+
+	owners := make(map[string]interface{}, len(voter.VoteHistory))
+	for _, vote := range voter.VoteHistory {
+		owners[strconv.FormatUint(uint64(vote.VoteId), 10)] = voter.VoterId
+	}
+	if err := pipe.HSet(ctx, RedisVoteIndexKey, owners).Err(); err != nil {
+		return err
+	}
+	return v.indexPollVoteChanges(ctx, pipe, voter, previous)
+}
+
+func redisPollVotesKeyFromId(id uint) string {
+	return fmt.Sprintf("%s%d", RedisPollVotesPrefix, id)
+}
+
+// indexPollVoteChanges keeps the hash of each poll's votes, vote id to
+// voter id, in step with the voter.  Only the polls whose votes were cast,
+// changed or removed are written, which is what lets changePoll WATCH the
+// hash of one poll rather than every vote.
+func (v *VoterList) indexPollVoteChanges(ctx context.Context, pipe redis.Cmdable, voter Voter, previous Voter) error {
+	for _, vote := range previous.VoteHistory {
+		current, err := voter.getVoteById(vote.VoteId)
+		if err != nil || current.PollId != vote.PollId {
+			if err := pipe.HDel(ctx, redisPollVotesKeyFromId(vote.PollId), strconv.FormatUint(uint64(vote.VoteId), 10)).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, vote := range changedVotes(voter, previous) {
+		if err := pipe.HSet(ctx, redisPollVotesKeyFromId(vote.PollId), strconv.FormatUint(uint64(vote.VoteId), 10), voter.VoterId).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexPollVotes builds the hashes of each poll's votes if they were never
+// built, for example when the votes were cast with an older version.
+// RedisPollVotesKey is set once it is done, so it only runs once.
+func (v *VoterList) indexPollVotes(ctx context.Context) error {
+	if exists, err := v.doesKeyExist(ctx, RedisPollVotesKey); exists || err != nil {
+		return err
+	}
+
+	voters, err := v.GetAllVoters(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = v.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, voter := range voters {
+			if err := v.indexPollVoteChanges(ctx, pipe, voter, Voter{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return v.client.Set(ctx, RedisPollVotesKey, 1, 0).Err()
+}
+
+// pollVoters reads the voters with a vote in the poll, going by the hash
+// of its votes
+func (v *VoterList) pollVoters(ctx context.Context, cmd redis.Cmdable, id uint) ([]Voter, error) {
+	ids, err := cmd.HVals(ctx, redisPollVotesKeyFromId(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keyList := make([]string, len(ids))
+	for idx, id := range ids {
+		keyList[idx] = RedisKeyPrefix + id
+	}
+	return v.getVoters(ctx, keyList)
+}
+
+// nextVoteId returns the next id that is neither in the vote index nor
+// reserved
+func (v *VoterList) nextVoteId(ctx context.Context, reserved map[uint]struct{}) (uint, error) {
+	for {
+		id, err := v.client.Incr(ctx, RedisVoteIdKey).Uint64()
+		if err != nil {
+			return 0, err
+		}
+		if _, taken := reserved[uint(id)]; taken {
+			continue
+		}
+
+		used, err := v.client.HExists(ctx, RedisVoteIndexKey, strconv.FormatUint(id, 10)).Result()
+		if err != nil {
+			return 0, err
+		}
+		if !used {
+			return uint(id), nil
+		}
+	}
+}
+
+// voteOwner looks the voter that cast the vote up in the vote index
+func (v *VoterList) voteOwner(ctx context.Context, id uint) (uint, error) {
+	owner, err := v.client.HGet(ctx, RedisVoteIndexKey, strconv.FormatUint(uint64(id), 10)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("%w %d", errNoVote, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return uint(owner), nil
+}
+
+func redisPollKeyFromId(id uint) string {
+	return fmt.Sprintf("%s%d", RedisPollKeyPrefix, id)
+}
+
+// insertPoll stores the poll unless there is one with its id already.  NX
+// makes the check and the write one command, so two clients adding the
+// same poll can't both succeed.
+func (v *VoterList) insertPoll(ctx context.Context, item *Poll) error {
+	slog.DebugContext(ctx, "Storing poll", "key", redisPollKeyFromId(item.PollId))
+	err := v.client.JSONSetMode(ctx, redisPollKeyFromId(item.PollId), ".", item, "NX").Err()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("%w: poll %d already exists", ErrConflict, item.PollId)
+	}
+	return err
+}
+
+func (v *VoterList) GetAllPolls(ctx context.Context) ([]Poll, error) {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	keyList, err := v.getAllKeys(ctx, RedisPollKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := v.getItemsFromRedis(ctx, keyList)
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make([]Poll, len(items))
+	for idx, item := range items {
+		if err := fromJsonString(item, &polls[idx]); err != nil {
+			return nil, err
+		}
+	}
+
+	return polls, nil
+}
+
+func (v *VoterList) AddPoll(ctx context.Context, poll Poll) error {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	return v.insertPoll(ctx, &poll)
+}
+
+func (v *VoterList) GetPoll(ctx context.Context, id uint) (Poll, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	var newPoll Poll
+	err := v.getItemFromRedis(ctx, redisPollKeyFromId(id), &newPoll)
+	if err != nil {
+		return Poll{}, err
+	}
+	return newPoll, nil
+}
+
+func (v *VoterList) DeletePoll(ctx context.Context, id uint) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	return v.deletePoll(ctx, id)
+}
+
+func (v *VoterList) deletePoll(ctx context.Context, id uint) error {
+	return v.changePoll(ctx, id, func(Poll) (*Poll, error) {
+		return nil, nil
+	})
+}
+
+// DeleteAllPolls applies the delete policy to every vote up front, so with
+// DeleteRestrict no poll is deleted if any has votes, and then deletes the
+// polls one by one like DeletePoll
+func (v *VoterList) DeleteAllPolls(ctx context.Context) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	voters, err := v.GetAllVoters(ctx)
+	if err != nil {
+		return err
+	}
+	err = v.removeVotes(ctx, voters, func(vote VoterHistory) bool {
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	keyList, err := v.getAllKeys(ctx, RedisPollKeyPrefix)
+	if err != nil {
+		return err
+	}
+	for _, key := range keyList {
+		id, err := strconv.ParseUint(strings.TrimPrefix(key, RedisPollKeyPrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		// A poll someone else deleted first is just as gone
+		if err := v.deletePoll(ctx, uint(id)); err != nil && !errors.Is(err, errNoPoll) {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdatePoll replaces the poll.  Votes for options that are no longer
+// part of the poll are handled according to the delete policy.
+func (v *VoterList) UpdatePoll(ctx context.Context, poll Poll) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	return v.changePoll(ctx, poll.PollId, func(Poll) (*Poll, error) {
+		return &poll, nil
+	})
+}
+
+func (v *VoterList) AddOption(ctx context.Context, pollId uint, option PollOption) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	return v.changePoll(ctx, pollId, func(current Poll) (*Poll, error) {
+		poll, err := current.AddOption(option)
+		return &poll, err
+	})
+}
+
+func (v *VoterList) UpdateOption(ctx context.Context, pollId uint, option PollOption) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	return v.changePoll(ctx, pollId, func(current Poll) (*Poll, error) {
+		poll, err := current.UpdateOption(option)
+		return &poll, err
+	})
+}
+
+func (v *VoterList) DeleteOption(ctx context.Context, pollId uint, optionId uint) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	return v.changePoll(ctx, pollId, func(current Poll) (*Poll, error) {
+		poll, err := current.DeleteOption(optionId)
+		return &poll, err
+	})
+}
+
+// changePoll replaces the poll with what change makes of it, or deletes it
+// when change returns nil, in a transaction that only commits while no
+// vote would be left for a poll or option that is gone.  Such votes are
+// dealt with by the delete policy first: DeleteRestrict returns an
+// ErrConflict, DeleteCascade removes them and tries again.
+//
+// The poll and the hash of its votes are WATCHed before the votes are
+// read.  Every voter write that casts, changes or removes a vote in the
+// poll writes the hash, so a vote cast before the commit makes the
+// transaction fail and start over.  The voter transactions WATCH the polls
+// of the votes they write in turn, see checkVoteReferences, so whichever
+// commits second sees the other.  Changes that remove no option can't
+// leave a vote behind, and don't read any voters.
+func (v *VoterList) changePoll(ctx context.Context, id uint, change func(current Poll) (*Poll, error)) error {
+	key := redisPollKeyFromId(id)
+	votesKey := redisPollVotesKeyFromId(id)
+	for i := 0; i < redisTxRetries; i++ {
+		var voters []Voter
+		var match func(vote VoterHistory) bool
+		var matched *VoterHistory
+		err := v.client.Watch(ctx, func(tx *redis.Tx) error {
+			pollJson, err := tx.JSONGet(ctx, key, ".").Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			if pollJson == "" {
+				return errNoPoll
+			}
+			var current Poll
+			if err := fromJsonString(pollJson, &current); err != nil {
+				return err
+			}
+
+			updated, err := change(current)
+			if err != nil {
+				return err
+			}
+
+			match = removedVotes(current, updated)
+			if match != nil {
+				voters, err = v.pollVoters(ctx, tx, id)
+				if err != nil {
+					return err
+				}
+				for _, voter := range voters {
+					for _, vote := range voter.VoteHistory {
+						if match(vote) {
+							matched = &vote
+							return nil
+						}
+					}
+				}
+			}
+
+			slog.DebugContext(ctx, "Changing poll", "key", key)
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if updated == nil {
+					return pipe.Del(ctx, key, votesKey).Err()
+				}
+				return pipe.JSONSet(ctx, key, ".", updated).Err()
+			})
+			return err
+		}, key, votesKey)
+
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil || matched == nil {
+			return err
+		}
+
+		if v.deletePolicy == DeleteRestrict {
+			return fmt.Errorf("%w: vote %d would be removed", ErrConflict, matched.VoteId)
+		}
+		if err := v.removeVotes(ctx, voters, match); err != nil {
+			return err
+		}
+	}
+	return errPollBusy
+}
+
+// removedVotes returns what picks out the votes that changing the poll to
+// updated leaves without a poll or option, or nil when it removes neither
+func removedVotes(current Poll, updated *Poll) func(vote VoterHistory) bool {
+	if updated == nil {
+		return func(vote VoterHistory) bool {
+			return vote.PollId == current.PollId
+		}
+	}
+
+	for _, option := range current.PollOptions {
+		if _, err := updated.GetOption(option.PollOptionId); err != nil {
+			return func(vote VoterHistory) bool {
+				return vote.PollId == updated.PollId && updated.checkVote(vote) != nil
+			}
+		}
+	}
+	return nil
+}
+
+func (v *VoterList) GetAllVotes(ctx context.Context) ([]Vote, error) {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	voters, err := v.GetAllVoters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := make([]Vote, 0)
+	for _, voter := range voters {
+		for _, vote := range voter.VoteHistory {
+			votes = append(votes, vote.ToVote())
+		}
+	}
+
+	return votes, nil
+}
+
+func (v *VoterList) GetVote(ctx context.Context, id uint) (Vote, error) {
+	ctx, cancel := v.read(ctx)
+	defer cancel()
+
+	voter, err := v.voterForVote(ctx, id)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	history, err := voter.getVoteById(id)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	vote := history.ToVote()
+	vote.Version = voter.Version
+	return vote, nil
+}
+
+// AddVote records a vote against the voter that cast it.  If the vote
+// does not have an id the next free id is assigned.  The stored vote
+// is returned.
+func (v *VoterList) AddVote(ctx context.Context, vote Vote) (Vote, error) {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	// A vote id the caller picked is claimed in the voter's transaction,
+	// one of ours is already nobody else's
+	claimed := vote.VoteId != 0
+	if !claimed {
+		var err error
+		vote.VoteId, err = v.nextVoteId(ctx, nil)
+		if err != nil {
+			return Vote{}, err
+		}
+	}
+	if vote.VoteDate.IsZero() {
+		vote.VoteDate = time.Now()
+	}
+
+	stored, err := v.updateVoter(ctx, vote.VoterId, func(tx *redis.Tx, voter Voter) (Voter, error) {
+		if err := checkVersion(voter, vote.Version); err != nil {
+			return Voter{}, err
+		}
+		if claimed {
+			if _, err := voter.getVoteById(vote.VoteId); err == nil {
+				return Voter{}, errors.New("vote id already exists")
+			}
+			if err := v.claimVoteIds(ctx, tx, voter.VoterId, []uint{vote.VoteId}); err != nil {
+				return Voter{}, err
+			}
+		}
+		return voter.AddVote(vote.ToHistory())
+	})
+	if err != nil {
+		return Vote{}, err
+	}
+
+	vote.Version = stored.Version
+	return vote, nil
+}
+
+// UpdateVote replaces the vote with the same id.  The stored vote is
+// returned.
+func (v *VoterList) UpdateVote(ctx context.Context, vote Vote) (Vote, error) {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	voterId, err := v.voteOwner(ctx, vote.VoteId)
+	if err != nil {
+		return Vote{}, err
+	}
+
+	if voterId != vote.VoterId {
+		return Vote{}, errors.New("vote cannot be moved to another voter")
+	}
+
+	stored, err := v.updateVoter(ctx, voterId, func(_ *redis.Tx, voter Voter) (Voter, error) {
+		if err := checkVersion(voter, vote.Version); err != nil {
+			return Voter{}, err
+		}
+		return voter.replaceVote(vote.ToHistory())
+	})
+	if err != nil {
+		return Vote{}, err
+	}
+
+	vote.Version = stored.Version
+	return vote, nil
+}
+
+func (v *VoterList) DeleteVote(ctx context.Context, id uint, version uint64) error {
+	ctx, cancel := v.write(ctx)
+	defer cancel()
+
+	voterId, err := v.voteOwner(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = v.updateVoter(ctx, voterId, func(_ *redis.Tx, voter Voter) (Voter, error) {
+		if err := checkVersion(voter, version); err != nil {
+			return Voter{}, err
+		}
+
+		vote, err := voter.getVoteById(id)
+		if err != nil {
+			return Voter{}, err
+		}
+		return voter.DeleteVote(vote.PollId)
+	})
+	return err
+}
+
+func (v *VoterList) DeleteAllVotes(ctx context.Context) error {
+	ctx, cancel := v.bulk(ctx)
+	defer cancel()
+
+	voters, err := v.GetAllVoters(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, voter := range voters {
+		if len(voter.VoteHistory) == 0 {
+			continue
+		}
+
+		_, err := v.updateVoter(ctx, voter.VoterId, func(_ *redis.Tx, voter Voter) (Voter, error) {
+			voter.VoteHistory = make([]VoterHistory, 0)
+			return voter, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return v.client.Del(ctx, RedisVoteIndexKey).Err()
+}
+
+func (v *VoterList) voterForVote(ctx context.Context, id uint) (Voter, error) {
+	voterId, err := v.voteOwner(ctx, id)
+	if err != nil {
+		return Voter{}, err
+	}
+
+	return v.GetVoter(ctx, voterId)
+}
+
+// checkVoteReferences makes sure every vote is for an option of a poll
+// that exists.  It has to be called inside the voter's transaction, before
+// the writes.  The polls are WATCHed, so a poll deleted or changed before
+// the transaction commits makes it fail and start over, see changePoll.
+func (v *VoterList) checkVoteReferences(ctx context.Context, tx *redis.Tx, votes []VoterHistory) error {
+	if len(votes) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(votes))
+	for i, vote := range votes {
+		keys[i] = redisPollKeyFromId(vote.PollId)
+	}
+	if err := tx.Watch(ctx, keys...).Err(); err != nil {
+		return err
+	}
+
+	for i, vote := range votes {
+		pollJson, err := tx.JSONGet(ctx, keys[i], ".").Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		if pollJson == "" {
+			return fmt.Errorf("%w: poll %d does not exist", ErrConflict, vote.PollId)
+		}
+
+		var poll Poll
+		if err := fromJsonString(pollJson, &poll); err != nil {
+			return err
+		}
+		if err := poll.checkVote(vote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changedVotes returns the votes of the voter that are new, or are for
+// another poll or option than in the previous version.  Those are the ones
+// whose polls need checking, the poll side keeps the others valid.
+func changedVotes(voter Voter, previous Voter) []VoterHistory {
+	var changed []VoterHistory
+	for _, vote := range voter.VoteHistory {
+		old, err := previous.getVoteById(vote.VoteId)
+		if err != nil || old.PollId != vote.PollId || old.VoteValue != vote.VoteValue {
+			changed = append(changed, vote)
+		}
+	}
+	return changed
+}
+
+// removeVotes applies the delete policy to the votes of the voters that
+// match.  With DeleteRestrict nothing is removed and ErrConflict is
+// returned if any vote matches.
+func (v *VoterList) removeVotes(ctx context.Context, voters []Voter, match func(vote VoterHistory) bool) error {
+	for _, voter := range voters {
+		if v.deletePolicy == DeleteRestrict {
+			for _, vote := range voter.VoteHistory {
+				if match(vote) {
+					return fmt.Errorf("%w: vote %d would be removed", ErrConflict, vote.VoteId)
+				}
+			}
+			continue
+		}
+
+		if !hasMatchingVote(voter, match) {
+			continue
+		}
+
+		_, err := v.updateVoter(ctx, voter.VoterId, func(_ *redis.Tx, voter Voter) (Voter, error) {
+			history := make([]VoterHistory, 0, len(voter.VoteHistory))
+			for _, vote := range voter.VoteHistory {
+				if !match(vote) {
+					history = append(history, vote)
+				}
+			}
+			voter.VoteHistory = history
+			return voter, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasMatchingVote(voter Voter, match func(vote VoterHistory) bool) bool {
+	for _, vote := range voter.VoteHistory {
+		if match(vote) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Voters are searched by the words in their name and email.  A search
+// matches the voters that have, for every word of the query, a word in their
+// name or email starting with it.  "ali place" finds alice@place.com, and
+// finds Alice Smith if she has a place.com address.
+//
+// The redis store answers searches from a RediSearch index, the in-memory
+// store scans its voters, both split the text into words the same way.
+
+// minSearchTermLength is the shortest word a search can have, RediSearch does
+// not expand shorter prefixes
+const minSearchTermLength = 2
+
+// searchWords splits text into lower case words.  Like RediSearch, anything
+// that is not a letter, a digit or an underscore separates words.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+// searchTerms returns the words of the query, which must have at least one
+// word long enough to search for.  A bad query is a *ValidationError.
+func searchTerms(query string) ([]string, error) {
+	terms := searchWords(query)
+	for _, term := range terms {
+		if len([]rune(term)) < minSearchTermLength {
+			return nil, &ValidationError{Fields: []FieldError{{
+				Field:  "q",
+				Reason: fmt.Sprintf("words must be at least %d characters", minSearchTermLength),
+			}}}
+		}
+	}
+	if len(terms) == 0 {
+		return nil, &ValidationError{Fields: []FieldError{{Field: "q", Reason: "is required"}}}
+	}
+	return terms, nil
+}
+
+// matchesSearch tells whether every term starts a word of the voter's name
+// or email
+func matchesSearch(voter Voter, terms []string) bool {
+	words := append(searchWords(voter.Name), searchWords(voter.Email)...)
+	for _, term := range terms {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	MemoryStore = "memory"
+	RedisStore  = "redis"
+)
+
+// VoterStore is the storage the api is built on.  VoterList keeps the data
+// in redis and MemoryVoterList keeps it in memory, so the service can run
+// without a redis instance.
+//
+// Changes to a voter, or to its votes, only happen if the voter is still at
+// the expected version, taken from Voter.Version, Vote.Version or the
+// version argument.  ErrPreconditionFailed is returned otherwise, passing
+// AnyVersion skips the check.
+//
+// No two voters may have the same email, AddVoter and UpdateVoter return an
+// ErrConflict rather than store a duplicate.  Both return the voter as it
+// was stored, with its new version and the ids given to its votes.  AddVote
+// and UpdateVote likewise return the vote with the new version of its
+// voter.
+//
+// AddOption, UpdateOption and DeleteOption change one option of the poll in
+// place, so two requests changing options of the same poll at once don't
+// lose either change.
+//
+// SearchVoters returns up to limit voters matching the query, in voter id
+// order, see search.go for what matches.
+//
+// Observe sets the Observer told about the votes cast and the redis commands
+// sent.  It has to be called before the store is used.
+//
+// Every operation takes the context of the request it is made for.  The
+// redis store gives up once the context is done, or its own Timeouts run
+// out, with an error wrapping ErrTimeout, and wraps errors reaching redis
+// in ErrUnavailable.  A change that keeps losing its transaction to other
+// clients gives up with an error wrapping ErrBusy.
+//
+// Ping checks that the store can be reached, within the deadline of ctx.
+// Close lets go of the store's connections once the api is done with it.
+type VoterStore interface {
+	GetAllVoters(ctx context.Context) ([]Voter, error)
+	GetVoterPage(ctx context.Context, query VoterQuery) (VoterPage, error)
+	AddVoter(ctx context.Context, voter Voter) (Voter, error)
+	GetVoter(ctx context.Context, id uint) (Voter, error)
+	GetVoterByEmail(ctx context.Context, email string) (Voter, error)
+	SearchVoters(ctx context.Context, query string, limit int) ([]Voter, error)
+	DeleteVoter(ctx context.Context, id uint, version uint64) error
+	DeleteAll(ctx context.Context) error
+	UpdateVoter(ctx context.Context, voter Voter) (Voter, error)
+
+	GetAllPolls(ctx context.Context) ([]Poll, error)
+	AddPoll(ctx context.Context, poll Poll) error
+	GetPoll(ctx context.Context, id uint) (Poll, error)
+	DeletePoll(ctx context.Context, id uint) error
+	DeleteAllPolls(ctx context.Context) error
+	UpdatePoll(ctx context.Context, poll Poll) error
+	AddOption(ctx context.Context, pollId uint, option PollOption) error
+	UpdateOption(ctx context.Context, pollId uint, option PollOption) error
+	DeleteOption(ctx context.Context, pollId uint, optionId uint) error
+
+	GetAllVotes(ctx context.Context) ([]Vote, error)
+	GetVote(ctx context.Context, id uint) (Vote, error)
+	AddVote(ctx context.Context, vote Vote) (Vote, error)
+	UpdateVote(ctx context.Context, vote Vote) (Vote, error)
+	DeleteVote(ctx context.Context, id uint, version uint64) error
+	DeleteAllVotes(ctx context.Context) error
+
+	Observe(observer Observer)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ VoterStore = (*VoterList)(nil)
+	_ VoterStore = (*MemoryVoterList)(nil)
+)
+
+// NewVoterStore creates the store named by kind, either "memory" or "redis".
+// Only the redis store has timeouts and a redis config, the memory store
+// never waits.
+func NewVoterStore(kind string, deletePolicy DeletePolicy, timeouts Timeouts, redisConfig RedisConfig) (VoterStore, error) {
+	switch kind {
+	case MemoryStore:
+		return NewMemoryVoterList(deletePolicy)
+	case RedisStore:
+		return NewVoterList(redisConfig, deletePolicy, timeouts)
+	default:
+		return nil, fmt.Errorf("unknown store %q, expected %s or %s", kind, MemoryStore, RedisStore)
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Timeouts are the deadlines the redis store gives each kind of operation,
+// on top of any deadline the caller's context already has.  Zero leaves
+// the operation to the caller's deadline.
+type Timeouts struct {
+	// Read is for reading a voter, poll or vote, a page of voters or a
+	// search
+	Read time.Duration
+
+	// Write is for adding, changing or deleting a voter, poll or vote
+	Write time.Duration
+
+	// Bulk is for operations that walk every voter or poll, like listing
+	// every vote or deleting a poll along with its votes
+	Bulk time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Read:  2 * time.Second,
+	Write: 5 * time.Second,
+	Bulk:  30 * time.Second,
+}
+
+// ErrTimeout is returned when the store did not answer within the
+// deadline, ErrUnavailable when it could not be reached at all, and ErrBusy
+// when a change lost the race to other clients changing the same voter or
+// poll too many times in a row.
+var (
+	ErrTimeout     = errors.New("store timed out")
+	ErrUnavailable = errors.New("store unavailable")
+	ErrBusy        = errors.New("store busy")
+)
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// storeError wraps the errors redis commands fail with when redis is slow
+// or gone in ErrTimeout or ErrUnavailable, and leaves any other error,
+// like a nil reply or a failed transaction, alone
+func storeError(err error) error {
+	if err == nil || unreachable(err) {
+		return err
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	case errors.Is(err, context.Canceled), errors.Is(err, redis.ErrClosed), errors.Is(err, io.EOF),
+		errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+// unreachable reports whether err is one storeError wrapped, where the store
+// could not give an answer rather than answering no
+func unreachable(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrUnavailable)
+}
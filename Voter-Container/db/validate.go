@@ -0,0 +1,116 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Voters and their votes are validated declaratively with validate struct
+// tags, see Voter and VoterHistory.  Besides the standard validator tags
+// there is notfuture, for dates that cannot be in the future.
+
+// maxClockSkew is how far in the future a notfuture date may be, so that a
+// client whose clock is a little ahead of ours is not rejected
+const maxClockSkew = time.Minute
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report fields by their json names, which is what clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	v.RegisterValidation("notfuture", func(fl validator.FieldLevel) bool {
+		date, ok := fl.Field().Interface().(time.Time)
+		return ok && !date.After(time.Now().Add(maxClockSkew))
+	})
+
+	return v
+}
+
+// FieldError describes why one field failed validation
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+// ValidationError lists every field that failed validation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+" "+field.Reason)
+	}
+	return "validation failed: " + strings.Join(fields, ", ")
+}
+
+// validateStruct checks item against its validate tags, the failures are
+// returned as a *ValidationError
+func validateStruct(item interface{}) error {
+	err := validate.Struct(item)
+
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err
+	}
+
+	failed := &ValidationError{}
+	for _, fieldError := range fieldErrors {
+		// The namespace starts with the struct name, which clients
+		// never see
+		_, field, _ := strings.Cut(fieldError.Namespace(), ".")
+		failed.Fields = append(failed.Fields, FieldError{
+			Field:  field,
+			Reason: reasonFor(fieldError),
+		})
+	}
+	return failed
+}
+
+func reasonFor(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "notfuture":
+		return "cannot be in the future"
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fieldError.Param())
+	}
+	return fmt.Sprintf("failed the %s check", fieldError.Tag())
+}
+
+// Validate checks the voter's fields and votes.  Any failures are returned
+// as a *ValidationError.
+func (v *Voter) Validate() error {
+	if err := validateStruct(v); err != nil {
+		return err
+	}
+
+	if err := v.ValidateVotes(); err != nil {
+		return &ValidationError{Fields: []FieldError{{Field: "voter_history", Reason: err.Error()}}}
+	}
+	return nil
+}
+
+// Validate checks the fields of a vote in a voter's history.  Any failures
+// are returned as a *ValidationError.
+func (h *VoterHistory) Validate() error {
+	return validateStruct(h)
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPreconditionFailed is returned when a change is made against a version
+// of a voter that is no longer the stored one, because somebody else changed
+// the voter first.  Use errors.Is to check for it.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// AnyVersion is passed as the expected version of a voter to make a change
+// whatever version is stored
+const AnyVersion uint64 = 0
+
+// checkVersion makes sure the voter is still at the expected version
+func checkVersion(voter Voter, expected uint64) error {
+	if expected != AnyVersion && voter.Version != expected {
+		return fmt.Errorf("%w: voter %d is at version %d, not %d",
+			ErrPreconditionFailed, voter.VoterId, voter.Version, expected)
+	}
+	return nil
+}
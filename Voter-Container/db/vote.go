@@ -0,0 +1,73 @@
+package db
+
+import (
+	"errors"
+	"time"
+)
+
+type Vote struct {
+	VoteId    uint      `json:"vote_id"`
+	VoterId   uint      `json:"voter_id"`
+	PollId    uint      `json:"poll_id"`
+	VoteValue uint      `json:"vote_value"`
+	VoteDate  time.Time `json:"vote_date"`
+
+	// Version is the version of the voter that cast the vote
+	Version uint64 `json:"-"`
+}
+
+// ToVote converts an entry in a voter's history into a top level Vote
+func (h VoterHistory) ToVote() Vote {
+	return Vote{
+		VoteId:    h.VoteId,
+		VoterId:   h.VoterId,
+		PollId:    h.PollId,
+		VoteValue: h.VoteValue,
+		VoteDate:  h.VoteDate,
+	}
+}
+
+// ToHistory converts a Vote into an entry for the voter's history
+func (v Vote) ToHistory() VoterHistory {
+	return VoterHistory{
+		PollId:    v.PollId,
+		VoterId:   v.VoterId,
+		VoteDate:  v.VoteDate,
+		VoteId:    v.VoteId,
+		VoteValue: v.VoteValue,
+	}
+}
+
+func (v *Voter) getVoteById(voteId uint) (VoterHistory, error) {
+	for _, vote := range v.VoteHistory {
+		if vote.VoteId == voteId {
+			return vote, nil
+		}
+	}
+	return VoterHistory{}, errors.New("vote does not exist")
+}
+
+// replaceVote swaps out the vote with the same vote id.  Unlike UpdateVote
+// the replacement may be for a different poll, as long as the voter has
+// not already voted in that poll.
+func (v Voter) replaceVote(updatedVote VoterHistory) (Voter, error) {
+	voteIndex := -1
+	for i, vote := range v.VoteHistory {
+		if vote.VoteId == updatedVote.VoteId {
+			voteIndex = i
+		} else if vote.PollId == updatedVote.PollId {
+			return Voter{}, errors.New("vote already exists")
+		}
+	}
+
+	if voteIndex < 0 {
+		return Voter{}, errors.New("vote does not exist")
+	}
+
+	history := make([]VoterHistory, len(v.VoteHistory))
+	copy(history, v.VoteHistory)
+	history[voteIndex] = updatedVote
+	v.VoteHistory = history
+
+	return v, nil
+}
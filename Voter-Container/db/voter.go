@@ -1,176 +1,37 @@
 package db
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
-	"os"
 	"time"
-
-	"github.com/redis/go-redis/v9"
-)
-
-const (
-	RedisNilError        = "redis: nil"
-	RedisDefaultLocation = "0.0.0.0:6379"
-	RedisKeyPrefix       = "voter:"
 )
 
-type cache struct {
-	client  *redis.Client
-	context context.Context
-}
-
+// The validate tags are checked by Validate, see validate.go.  Poll ids are
+// not required as polls are free to start at 0.
 type VoterHistory struct {
-	PollId   uint      `json:"poll_id"`
-	VoterId  uint      `json:"voter_id"`
-	VoteDate time.Time `json:"vote_date"`
+	PollId    uint      `json:"poll_id"`
+	VoterId   uint      `json:"voter_id" validate:"required"`
+	VoteDate  time.Time `json:"vote_date" validate:"notfuture"`
+	VoteId    uint      `json:"vote_id"`
+	VoteValue uint      `json:"vote_value"`
 }
 
 type Voter struct {
-	VoterId     uint           `json:"voter_id"`
-	Name        string         `json:"name"`
-	Email       string         `json:"email"`
-	VoteHistory []VoterHistory `json:"voter_history"`
-}
-
-type VoterList struct {
-	cache
-}
-
-// constructor for VoterList struct
-func NewVoterList() (*VoterList, error) {
-	redisUrl := os.Getenv("REDIS_URL")
-	//This handles the default condition
-	if redisUrl == "" {
-		redisUrl = RedisDefaultLocation
-	}
-	return NewWithCacheInstance(redisUrl)
-}
-
-func NewWithCacheInstance(location string) (*VoterList, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr: location,
-	})
-
-	ctx := context.TODO()
-
-	err := client.Ping(ctx).Err()
-	if err != nil {
-		log.Println("Error connecting to redis" + err.Error())
-		return nil, err
-	}
-
-	return &VoterList{
-		cache: cache{
-			client:  client,
-			context: ctx,
-		},
-	}, nil
-}
-
-// func isRedisNilError(err error) bool {
-// 	return errors.Is(err, redis.Nil) || err.Error() == RedisNilError
-// }
+	VoterId     uint           `json:"voter_id" validate:"required"`
+	Name        string         `json:"name" validate:"required,max=100"`
+	Email       string         `json:"email" validate:"required,email"`
+	VoteHistory []VoterHistory `json:"voter_history" validate:"dive"`
 
-func redisKeyFromId(id uint) string {
-	return fmt.Sprintf("%s%d", RedisKeyPrefix, id)
+	// Version goes up every time the voter is stored.  It is sent to
+	// clients as the voter's ETag rather than as part of the voter.
+	Version uint64 `json:"-"`
 }
 
-func (v *VoterList) getAllKeys() ([]string, error) {
-	key := fmt.Sprintf("%s*", RedisKeyPrefix)
-	return v.client.Keys(v.context, key).Result()
-}
-
-func fromJsonString(s string, item *Voter) error {
-	err := json.Unmarshal([]byte(s), &item)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (v *VoterList) upsertVoter(item *Voter) error {
-	log.Println("Adding new Id:", redisKeyFromId(item.VoterId))
-	return v.client.JSONSet(v.context, redisKeyFromId(item.VoterId), ".", item).Err()
-}
-
-// Helper to return a ToDoItem from redis provided a key
-func (v *VoterList) getItemFromRedis(key string, item *Voter) error {
-	itemJson, err := v.client.JSONGet(v.context, key, ".").Result()
-	if err != nil {
-		return err
-	}
-
-	return fromJsonString(itemJson, item)
-}
-
-func (t *VoterList) doesKeyExist(id uint) bool {
-	kc, _ := t.client.Exists(t.context, redisKeyFromId(id)).Result()
-	return kc > 0
-}
-
-func (v *VoterList) GetAllVoters() ([]Voter, error) {
-	keyList, err := v.getAllKeys()
-	if err != nil {
-		return nil, err
-	}
-	voters := make([]Voter, len(keyList))
-
-	for idx, key := range keyList {
-		err := v.getItemFromRedis(key, &voters[idx])
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	return voters, nil
-}
-
-func (v *VoterList) AddVoter(voter Voter) error {
-	if v.doesKeyExist(voter.VoterId) {
-		return fmt.Errorf("Voter with id %d already exists", voter.VoterId)
-	}
-	return v.upsertVoter(&voter)
-}
-
-func (v *VoterList) GetVoter(id uint) (Voter, error) {
-	var newVoter Voter
-	err := v.getItemFromRedis(redisKeyFromId(id), &newVoter)
-	if err != nil {
-		return Voter{}, err
-	}
-	return newVoter, nil
-}
-
-func (v *VoterList) DeleteVoter(id uint) error {
-
-	if !v.doesKeyExist(id) {
-		return errors.New("no voter for id")
-	}
-
-	return v.client.Del(v.context, redisKeyFromId(id)).Err()
-}
-
-func (v *VoterList) DeleteAll() error {
-	keyList, err := v.getAllKeys()
-	if err != nil {
-		return err
-	}
-	if len(keyList) == 0 {
-		return nil
-	}
-	return v.client.Del(v.context, keyList...).Err()
-}
-
-func (v *VoterList) UpdateVoter(voter Voter) error {
-	if !v.doesKeyExist(voter.VoterId) {
-		return errors.New("Voter does not exist")
-	}
-
-	return v.upsertVoter(&voter)
+// VoterPage is one page of the voters picked by a VoterQuery.  When More is
+// set Next is the cursor, or the offset, to pass to get the following page.
+type VoterPage struct {
+	Voters []Voter
+	Next   uint
+	More   bool
 }
 
 func (v *Voter) GetVote(pollId uint) (VoterHistory, error) {
@@ -189,7 +50,9 @@ func (v Voter) AddVote(newVote VoterHistory) (Voter, error) {
 		}
 	}
 
-	v.VoteHistory = append(v.VoteHistory, newVote)
+	history := make([]VoterHistory, len(v.VoteHistory), len(v.VoteHistory)+1)
+	copy(history, v.VoteHistory)
+	v.VoteHistory = append(history, newVote)
 	return v, nil
 }
 
@@ -208,7 +71,10 @@ func (v Voter) UpdateVote(updatedVote VoterHistory) (Voter, error) {
 		return Voter{}, errors.New("vote does not exist")
 	}
 
-	v.VoteHistory[voteIndex] = updatedVote
+	history := make([]VoterHistory, len(v.VoteHistory))
+	copy(history, v.VoteHistory)
+	history[voteIndex] = updatedVote
+	v.VoteHistory = history
 
 	return v, nil
 }
@@ -228,12 +94,15 @@ func (v Voter) DeleteVote(pollId uint) (Voter, error) {
 		return Voter{}, errors.New("vote does not exist")
 	}
 
-	v.VoteHistory = append(v.VoteHistory[:voteIndex], v.VoteHistory[voteIndex+1:]...)
+	history := make([]VoterHistory, 0, len(v.VoteHistory)-1)
+	history = append(history, v.VoteHistory[:voteIndex]...)
+	v.VoteHistory = append(history, v.VoteHistory[voteIndex+1:]...)
 	return v, nil
 }
 
 func (v *Voter) ValidateVotes() error {
 	var seen = make(map[uint]struct{})
+	var seenIds = make(map[uint]struct{})
 	for _, vote := range v.VoteHistory {
 		_, ok := seen[vote.PollId]
 		if ok {
@@ -243,7 +112,40 @@ func (v *Voter) ValidateVotes() error {
 		if vote.VoterId != v.VoterId {
 			return errors.New("vote does not match voter")
 		}
+		if vote.VoteId == 0 {
+			continue
+		}
+		if _, ok := seenIds[vote.VoteId]; ok {
+			return errors.New("duplicate vote id found")
+		}
+		seenIds[vote.VoteId] = struct{}{}
 	}
 
 	return nil
 }
+
+// voteIds is the set of ids the votes in the history already have, which
+// must not be given to its votes without one
+func (v Voter) voteIds() map[uint]struct{} {
+	ids := make(map[uint]struct{}, len(v.VoteHistory))
+	for _, vote := range v.VoteHistory {
+		if vote.VoteId != 0 {
+			ids[vote.VoteId] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// carryVoteIds fills in missing vote ids using the id of the vote for
+// the same poll in a previous version of the voter.  This lets clients
+// replace a voter without having to echo back every vote id.
+func (v *Voter) carryVoteIds(previous Voter) {
+	for i, vote := range v.VoteHistory {
+		if vote.VoteId != 0 {
+			continue
+		}
+		if old, err := previous.GetVote(vote.PollId); err == nil {
+			v.VoteHistory[i].VoteId = old.VoteId
+		}
+	}
+}
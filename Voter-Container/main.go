@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"drexel.edu/voter/api"
+	"drexel.edu/voter/db"
+	"drexel.edu/voter/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -15,16 +23,122 @@ import (
 // Global variables to hold the command line flags to drive the todo CLI
 // application
 var (
-	hostFlag string
-	portFlag uint
+	hostFlag         string
+	portFlag         uint
+	deletePolicyFlag string
+	storeFlag        string
+
+	shutdownTimeoutFlag time.Duration
+	drainDelayFlag      time.Duration
+	logLevelFlag        string
+	storeTimeouts       db.Timeouts
+
+	redisConfig            db.RedisConfig
+	redisSentinelAddrsFlag string
 )
 
 func processCmdLineFlags() {
 
 	flag.StringVar(&hostFlag, "h", "0.0.0.0", "Listen on all interfaces")
 	flag.UintVar(&portFlag, "p", 1080, "Default Port")
+	flag.StringVar(&deletePolicyFlag, "delete-policy", envOrDefault("DELETE_POLICY", "cascade"),
+		"What happens to votes when their voter, poll or option is deleted: cascade or restrict")
+	flag.StringVar(&storeFlag, "store", envOrDefault("VOTER_STORE", db.RedisStore),
+		"Where voters, polls and votes are kept: memory or redis")
+	flag.DurationVar(&shutdownTimeoutFlag, "shutdown-timeout", durationEnvOrDefault("SHUTDOWN_TIMEOUT", 10*time.Second),
+		"How long requests in flight get to finish when the server is stopped")
+	flag.DurationVar(&drainDelayFlag, "drain-delay", durationEnvOrDefault("DRAIN_DELAY", 0),
+		"How long the server keeps serving, while not ready, once it is told to stop, so load balancers can stop sending it requests")
+	flag.DurationVar(&storeTimeouts.Read, "store-read-timeout", durationEnvOrDefault("STORE_READ_TIMEOUT", db.DefaultTimeouts.Read),
+		"How long redis gets to read a voter, poll, vote, page or search, 0 for no limit")
+	flag.DurationVar(&storeTimeouts.Write, "store-write-timeout", durationEnvOrDefault("STORE_WRITE_TIMEOUT", db.DefaultTimeouts.Write),
+		"How long redis gets to add, change or delete a voter, poll or vote, 0 for no limit")
+	flag.DurationVar(&storeTimeouts.Bulk, "store-bulk-timeout", durationEnvOrDefault("STORE_BULK_TIMEOUT", db.DefaultTimeouts.Bulk),
+		"How long redis gets for operations on every voter, poll or vote, 0 for no limit")
+	flag.StringVar(&redisConfig.URL, "redis-url", envOrDefault("REDIS_URL", db.RedisDefaultLocation),
+		"Where redis is: redis://[username:password@]host:port[/db], rediss:// for TLS, or host:port")
+	flag.StringVar(&redisConfig.Username, "redis-username", os.Getenv("REDIS_USERNAME"),
+		"The ACL user to log in to redis as, in place of the one in the url")
+	flag.StringVar(&redisConfig.Password, "redis-password", os.Getenv("REDIS_PASSWORD"),
+		"The redis password, in place of the one in the url.  Prefer REDIS_PASSWORD, flags show up in ps")
+	flag.IntVar(&redisConfig.DB, "redis-db", intEnvOrDefault("REDIS_DB", -1),
+		"The redis database index, in place of the one in the url")
+	flag.BoolVar(&redisConfig.TLS, "redis-tls", boolEnvOrDefault("REDIS_TLS", false),
+		"Connect to redis with TLS even though the url is redis://")
+	flag.StringVar(&redisConfig.CACertFile, "redis-ca-cert", os.Getenv("REDIS_CA_CERT"),
+		"A PEM file of the CA certificates to check redis's certificate against")
+	flag.StringVar(&redisConfig.SentinelMaster, "redis-sentinel-master", os.Getenv("REDIS_SENTINEL_MASTER"),
+		"Find the redis master with this name through Sentinel")
+	flag.StringVar(&redisSentinelAddrsFlag, "redis-sentinel-addrs", os.Getenv("REDIS_SENTINEL_ADDRS"),
+		"Comma separated host:port of the Sentinels, the url's host if there are none")
+	flag.StringVar(&redisConfig.SentinelUsername, "redis-sentinel-username", os.Getenv("REDIS_SENTINEL_USERNAME"),
+		"The ACL user to log in to the Sentinels as")
+	flag.StringVar(&redisConfig.SentinelPassword, "redis-sentinel-password", os.Getenv("REDIS_SENTINEL_PASSWORD"),
+		"The password of the Sentinels.  Prefer REDIS_SENTINEL_PASSWORD, flags show up in ps")
+	flag.StringVar(&logLevelFlag, "log-level", envOrDefault("LOG_LEVEL", "info"),
+		"The least important log lines written: debug, info, warn or error")
 
 	flag.Parse()
+
+	for _, addr := range strings.Split(redisSentinelAddrsFlag, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			redisConfig.SentinelAddrs = append(redisConfig.SentinelAddrs, addr)
+		}
+	}
+}
+
+// envOrDefault lets environment variables provide the defaults for
+// command line flags, which is handy when running in a container
+func envOrDefault(key string, value string) string {
+	if env := os.Getenv(key); env != "" {
+		return env
+	}
+	return value
+}
+
+// durationEnvOrDefault is envOrDefault for duration flags
+func durationEnvOrDefault(key string, value time.Duration) time.Duration {
+	env := os.Getenv(key)
+	if env == "" {
+		return value
+	}
+
+	duration, err := time.ParseDuration(env)
+	if err != nil {
+		fmt.Printf("%s must be a duration like 10s: %v\n", key, err)
+		os.Exit(1)
+	}
+	return duration
+}
+
+// intEnvOrDefault is envOrDefault for int flags
+func intEnvOrDefault(key string, value int) int {
+	env := os.Getenv(key)
+	if env == "" {
+		return value
+	}
+
+	n, err := strconv.Atoi(env)
+	if err != nil {
+		fmt.Printf("%s must be a number: %v\n", key, err)
+		os.Exit(1)
+	}
+	return n
+}
+
+// boolEnvOrDefault is envOrDefault for bool flags
+func boolEnvOrDefault(key string, value bool) bool {
+	env := os.Getenv(key)
+	if env == "" {
+		return value
+	}
+
+	b, err := strconv.ParseBool(env)
+	if err != nil {
+		fmt.Printf("%s must be true or false: %v\n", key, err)
+		os.Exit(1)
+	}
+	return b
 }
 
 // main is the entry point for our todo API application.  It processes
@@ -33,38 +147,125 @@ func processCmdLineFlags() {
 func main() {
 	processCmdLineFlags()
 
-	app := fiber.New()
+	logLevel, err := logging.ParseLevel(logLevelFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// Lines written with the log package come out as JSON too
+	slog.SetDefault(slog.New(logging.NewHandler(os.Stdout, logLevel)))
+
+	// The startup banner is not JSON, the server logs that it started
+	app := fiber.New(fiber.Config{ErrorHandler: api.ErrorHandler, DisableStartupMessage: true})
+
+	// Cancelled once shutting down has given up on the requests in flight,
+	// so those still waiting on redis stop rather than hold the store open
+	requests, cancelRequests := context.WithCancel(context.Background())
+	app.Use(api.RequestContext(requests))
+	app.Use(api.LogRequests)
 	app.Use(cors.New())
 	app.Use(recover.New())
 
-	apiHandler, err := api.New()
+	deletePolicy, err := db.ParseDeletePolicy(deletePolicyFlag)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	store, err := db.NewVoterStore(storeFlag, deletePolicy, storeTimeouts, redisConfig)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	apiHandler := api.New(store)
+
 	//HTTP Standards for "REST" APIS
 	//GET - Read/Query
 	//POST - Create
 	//PUT - Update
+	//PATCH - Partial update with a JSON merge patch
 	//DELETE - Delete
 
 	app.Use("/voters", apiHandler.HandleStats)
 	app.Get("/voters", apiHandler.ListAllVoters)
+	app.Get("/voters/search", apiHandler.SearchVoters) // before /voters/:id so search is not taken for an id
 	app.Post("/voters", apiHandler.AddVoter)
 	app.Put("/voters/:id", apiHandler.UpdateVoter)
+	app.Patch("/voters/:id", apiHandler.PatchVoter)
 	app.Delete("/voters", apiHandler.DeleteAllVoters)
 	app.Delete("/voters/:id", apiHandler.DeleteVoter)
 	app.Get("/voters/:id", apiHandler.GetVoter)
-	app.Get("/voters/:id/polls", apiHandler.GetAllVotes)
-	app.Post("/voters/:id/polls", apiHandler.AddVote)
-	app.Get("/voters/:id/polls/:pollid", apiHandler.GetVote)
-	app.Put("/voters/:id/polls/:pollid", apiHandler.UpdateVote)
-	app.Delete("/voters/:id/polls/:pollid", apiHandler.DeleteVote)
+	app.Get("/voters/:id/polls", apiHandler.GetAllVoterPolls)
+	app.Post("/voters/:id/polls", apiHandler.AddVoterPoll)
+	app.Get("/voters/:id/polls/:pollid", apiHandler.GetVoterPoll)
+	app.Put("/voters/:id/polls/:pollid", apiHandler.UpdateVoterPoll)
+	app.Patch("/voters/:id/polls/:pollid", apiHandler.PatchVoterPoll)
+	app.Delete("/voters/:id/polls/:pollid", apiHandler.DeleteVoterPoll)
+
+	app.Use("/polls", apiHandler.HandleStats)
+	app.Get("/polls", apiHandler.ListAllPolls)
+	app.Post("/polls", apiHandler.AddPoll)
+	app.Put("/polls/:id", apiHandler.UpdatePoll)
+	app.Delete("/polls", apiHandler.DeleteAllPolls)
+	app.Delete("/polls/:id", apiHandler.DeletePoll)
+	app.Get("/polls/:id", apiHandler.GetPoll)
+	app.Get("/polls/:id/options", apiHandler.GetAllOptions)
+	app.Post("/polls/:id/options", apiHandler.AddOption)
+	app.Get("/polls/:id/options/:optionid", apiHandler.GetOption)
+	app.Put("/polls/:id/options/:optionid", apiHandler.UpdateOption)
+	app.Delete("/polls/:id/options/:optionid", apiHandler.DeleteOption)
+
+	app.Use("/votes", apiHandler.HandleStats)
+	app.Get("/votes", apiHandler.ListAllVotes)
+	app.Post("/votes", apiHandler.AddVote)
+	app.Put("/votes/:id", apiHandler.UpdateVote)
+	app.Delete("/votes", apiHandler.DeleteAllVotes)
+	app.Delete("/votes/:id", apiHandler.DeleteVote)
+	app.Get("/votes/:id", apiHandler.GetVote)
 
-	app.Get("/health", apiHandler.HealthCheck)
+	app.Get("/", apiHandler.Home)
+	app.Get("/health", apiHandler.Ready) // kept for clients of the old combined check
+	app.Get("/health/live", apiHandler.Live)
+	app.Get("/health/ready", apiHandler.Ready)
+	app.Get("/metrics", apiHandler.Metrics)
+
+	// SIGTERM is what docker and kubernetes send to stop the container
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	serverPath := fmt.Sprintf("%s:%d", hostFlag, portFlag)
-	log.Println("Starting server on ", serverPath)
-	app.Listen(serverPath)
+	listenErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting server", "address", serverPath, "store", storeFlag)
+		listenErr <- app.Listen(serverPath)
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-listenErr:
+		slog.Error("Error running server", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		// Readiness probes see the api draining while it still serves, so
+		// it is taken out of the load balancer before it stops listening
+		apiHandler.Drain()
+		if drainDelayFlag > 0 {
+			slog.Info("Draining before shutting down", "delay", drainDelayFlag.String())
+			time.Sleep(drainDelayFlag)
+		}
+
+		slog.Info("Shutting down, waiting for requests to finish", "timeout", shutdownTimeoutFlag.String())
+		if err := app.ShutdownWithTimeout(shutdownTimeoutFlag); err != nil {
+			slog.Error("Error shutting down", "error", err)
+			exitCode = 1
+		}
+	}
+	cancelRequests()
+	stop()
+
+	if err := store.Close(); err != nil {
+		slog.Error("Error closing the store", "error", err)
+		exitCode = 1
+	}
+	os.Exit(exitCode)
 }
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// The concurrency tests hammer a single voter with votes for many polls at
+// once.  Every vote has to survive, none may be lost to another request
+// overwriting the voter.
+const (
+	concurrentVoter     = 600
+	concurrentFirstPoll = 600
+	concurrentPolls     = 40
+)
+
+var (
+	concurrentVotes []db.Vote
+)
+
+func concurrently(n int, run func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func getConcurrentVoter(t *testing.T) db.Voter {
+	var voter db.Voter
+	rsp, err := cli.R().SetResult(&voter).Get(fmt.Sprintf("%s/voters/%d", BASE_API, concurrentVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	return voter
+}
+
+func Test_LoadConcurrentData(t *testing.T) {
+	rsp, err := cli.R().SetBody(newRandVoter(concurrentVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	for i := uint(0); i < concurrentPolls; i++ {
+		poll := newRandPoll(concurrentFirstPoll + i)
+		poll.PollOptions = append(poll.PollOptions, newRandOption(0), newRandOption(1))
+
+		rsp, err = cli.R().SetBody(poll).Post(BASE_API + "/polls")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_ConcurrentAddVotes(t *testing.T) {
+	var mu sync.Mutex
+
+	concurrently(concurrentPolls, func(i int) {
+		vote := newRandVoteResource(concurrentVoter, concurrentFirstPoll+uint(i))
+		vote.VoteValue = 0
+
+		// Half of the votes go through the voter's poll history, the
+		// other half through the votes resource
+		var rsp *resty.Response
+		var err error
+		if i%2 == 0 {
+			var history db.VoterHistory
+			rsp, err = cli.R().SetBody(vote.ToHistory()).SetResult(&history).
+				Post(fmt.Sprintf("%s/voters/%d/polls", BASE_API, concurrentVoter))
+			vote = history.ToVote()
+		} else {
+			rsp, err = cli.R().SetBody(vote).SetResult(&vote).Post(BASE_API + "/votes")
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+
+		mu.Lock()
+		concurrentVotes = append(concurrentVotes, vote)
+		mu.Unlock()
+	})
+
+	voter := getConcurrentVoter(t)
+	assert.Equal(t, concurrentPolls, len(voter.VoteHistory), "expected no vote to be lost")
+
+	ids := make(map[uint]bool)
+	for _, vote := range voter.VoteHistory {
+		ids[vote.VoteId] = true
+	}
+	assert.Equal(t, concurrentPolls, len(ids), "expected every vote to have its own id")
+}
+
+func Test_ConcurrentUpdateVotes(t *testing.T) {
+	concurrently(len(concurrentVotes), func(i int) {
+		vote := concurrentVotes[i]
+		vote.VoteValue = 1
+
+		rsp, err := cli.R().SetBody(vote).Put(voteUrl(vote.VoteId))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	})
+
+	voter := getConcurrentVoter(t)
+	assert.Equal(t, concurrentPolls, len(voter.VoteHistory), "expected no vote to be lost")
+	for _, vote := range voter.VoteHistory {
+		assert.Equal(t, uint(1), vote.VoteValue, "expected every update to be kept")
+	}
+}
+
+func Test_ConcurrentDeleteVotes(t *testing.T) {
+	concurrently(len(concurrentVotes), func(i int) {
+		rsp, err := cli.R().Delete(voteUrl(concurrentVotes[i].VoteId))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	})
+
+	voter := getConcurrentVoter(t)
+	assert.Equal(t, 0, len(voter.VoteHistory), "expected every vote to be deleted")
+}
+
+// Options added to one poll at once are all kept, none is lost to another
+// request writing back the poll it read before the option was added
+func Test_ConcurrentAddOptions(t *testing.T) {
+	const options = 20
+	url := fmt.Sprintf("%s/polls/%d", BASE_API, concurrentFirstPoll)
+
+	concurrently(options, func(i int) {
+		rsp, err := cli.R().SetBody(newRandOption(uint(i + 2))).Post(url + "/options")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	})
+
+	var poll db.Poll
+	rsp, err := cli.R().SetResult(&poll).Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, options+2, len(poll.PollOptions), "expected no option to be lost")
+}
+
+func Test_CleanupConcurrentData(t *testing.T) {
+	rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, concurrentVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	for i := uint(0); i < concurrentPolls; i++ {
+		rsp, err = cli.R().Delete(fmt.Sprintf("%s/polls/%d", BASE_API, concurrentFirstPoll+i))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The email tests use their own voters and remove them when they are done.
+const (
+	emailVoter      = 1000
+	emailOtherVoter = 1001
+)
+
+func emailVoterUrl(id uint) string {
+	return fmt.Sprintf("%s/voters/%d", BASE_API, id)
+}
+
+func getVotersByEmail(t *testing.T, email string) []db.Voter {
+	var voters []db.Voter
+	rsp, err := cli.R().SetQueryParam("email", email).SetResult(&voters).Get(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	return voters
+}
+
+func Test_LoadEmailData(t *testing.T) {
+	voter := newRandVoter(emailVoter)
+	voter.Email = "unique@place.com"
+	rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().SetBody(newRandVoter(emailOtherVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_AddVoterDuplicateEmail(t *testing.T) {
+	voter := newRandVoter(emailOtherVoter + 1)
+	voter.Email = "Unique@Place.com"
+
+	rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode(), "expected emails to be compared without case")
+
+	rsp, err = cli.R().Get(emailVoterUrl(emailOtherVoter + 1))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected the duplicate not to be stored")
+}
+
+func Test_UpdateVoterDuplicateEmail(t *testing.T) {
+	voter := newRandVoter(emailOtherVoter)
+	voter.Email = "unique@place.com"
+
+	rsp, err := cli.R().SetBody(voter).Put(emailVoterUrl(emailOtherVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"email": "unique@place.com"}`).Patch(emailVoterUrl(emailOtherVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_GetVoterByEmail(t *testing.T) {
+	voters := getVotersByEmail(t, "unique@place.com")
+	assert.Equal(t, 1, len(voters))
+	if len(voters) == 1 {
+		assert.Equal(t, uint(emailVoter), voters[0].VoterId)
+	}
+
+	voters = getVotersByEmail(t, "nobody@place.com")
+	assert.Equal(t, 0, len(voters))
+}
+
+func Test_ChangeEmailFreesOldEmail(t *testing.T) {
+	voter := newRandVoter(emailVoter)
+	voter.Email = "changed@place.com"
+
+	rsp, err := cli.R().SetBody(voter).Put(emailVoterUrl(emailVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, 0, len(getVotersByEmail(t, "unique@place.com")))
+	assert.Equal(t, 1, len(getVotersByEmail(t, "changed@place.com")))
+
+	// The old email is free for another voter to take
+	voter = newRandVoter(emailOtherVoter)
+	voter.Email = "unique@place.com"
+	rsp, err = cli.R().SetBody(voter).Put(emailVoterUrl(emailOtherVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_CleanupEmailData(t *testing.T) {
+	for _, id := range []uint{emailVoter, emailOtherVoter} {
+		rsp, err := cli.R().Delete(emailVoterUrl(id))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+
+	assert.Equal(t, 0, len(getVotersByEmail(t, "unique@place.com")),
+		"expected deleting a voter to free its email")
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The ETag tests use their own voter and remove it when they are done.
+const (
+	etagVoter = 700
+)
+
+func etagVoterUrl() string {
+	return fmt.Sprintf("%s/voters/%d", BASE_API, etagVoter)
+}
+
+func getVoterETag(t *testing.T) string {
+	rsp, err := cli.R().Get(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	etag := rsp.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	return etag
+}
+
+func Test_LoadETagData(t *testing.T) {
+	rsp, err := cli.R().SetBody(newRandVoter(etagVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, getVoterETag(t), rsp.Header().Get("ETag"), "expected the ETag of the added voter")
+}
+
+func Test_IfNoneMatch(t *testing.T) {
+	etag := getVoterETag(t)
+
+	rsp, err := cli.R().SetHeader("If-None-Match", etag).Get(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 304, rsp.StatusCode())
+	assert.Empty(t, rsp.Body())
+
+	rsp, err = cli.R().SetHeader("If-None-Match", `"0"`).Get(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_IfNoneMatchVoterList(t *testing.T) {
+	url := fmt.Sprintf("%s/voters?limit=1&cursor=%d", BASE_API, etagVoter)
+
+	rsp, err := cli.R().Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	etag := rsp.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rsp, err = cli.R().SetHeader("If-None-Match", etag).Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 304, rsp.StatusCode())
+}
+
+func Test_UpdateVoterIfMatch(t *testing.T) {
+	etag := getVoterETag(t)
+
+	voter := newRandVoter(etagVoter)
+	rsp, err := cli.R().SetHeader("If-Match", etag).SetBody(voter).Put(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	updated := getVoterETag(t)
+	assert.NotEqual(t, etag, updated, "expected the ETag to change with the voter")
+	assert.Equal(t, updated, rsp.Header().Get("ETag"), "expected the ETag of the updated voter")
+
+	// The first update moved the voter on, so the old ETag is stale
+	rsp, err = cli.R().SetHeader("If-Match", etag).SetBody(voter).Put(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("If-Match", "not-an-etag").SetBody(voter).Put(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	assert.Equal(t, updated, getVoterETag(t), "expected failed updates to leave the voter alone")
+}
+
+func Test_VoteIfMatch(t *testing.T) {
+	etag := getVoterETag(t)
+
+	vote := newRandVote(etagVoter, 0)
+	vote.VoteValue = 1
+	rsp, err := cli.R().SetHeader("If-Match", `"999999"`).SetBody(vote).
+		Post(etagVoterUrl() + "/polls")
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("If-Match", etag).SetBody(vote).SetResult(&vote).
+		Post(etagVoterUrl() + "/polls")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	added := rsp.Header().Get("ETag")
+	assert.Equal(t, getVoterETag(t), added, "expected the ETag of the updated voter")
+
+	// The vote shares the voter's ETag
+	rsp, err = cli.R().SetHeader("If-None-Match", added).Get(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 304, rsp.StatusCode())
+
+	update := vote.ToVote()
+	update.VoteValue = 2
+	rsp, err = cli.R().SetHeader("If-Match", etag).SetBody(update).Put(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("If-Match", etag).Delete(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	// Every way of changing the vote sends the voter's new ETag, which the
+	// next change can be made with
+	rsp, err = cli.R().SetHeader("If-Match", added).SetBody(update).Put(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	updated := rsp.Header().Get("ETag")
+	assert.Equal(t, getVoterETag(t), updated, "expected the ETag of the updated voter")
+
+	vote.VoteValue = 3
+	rsp, err = cli.R().SetHeader("If-Match", updated).SetBody(vote).
+		Put(fmt.Sprintf("%s/polls/%d", etagVoterUrl(), vote.PollId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	updated = rsp.Header().Get("ETag")
+	assert.Equal(t, getVoterETag(t), updated, "expected the ETag of the updated voter")
+
+	rsp, err = cli.R().SetHeader("If-Match", updated).
+		SetHeader("Content-Type", "application/merge-patch+json").
+		SetBody(`{"vote_value": 1}`).
+		Patch(fmt.Sprintf("%s/polls/%d", etagVoterUrl(), vote.PollId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	updated = rsp.Header().Get("ETag")
+	assert.Equal(t, getVoterETag(t), updated, "expected the ETag of the patched voter")
+
+	rsp, err = cli.R().SetHeader("If-Match", updated).Delete(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_DeleteVoterIfMatch(t *testing.T) {
+	rsp, err := cli.R().SetHeader("If-Match", `"999999"`).Delete(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+
+	var voter db.Voter
+	rsp, err = cli.R().SetResult(&voter).Get(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "expected the voter to survive a failed delete")
+
+	rsp, err = cli.R().SetHeader("If-Match", rsp.Header().Get("ETag")).Delete(etagVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
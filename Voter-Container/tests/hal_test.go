@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	HAL_JSON = "application/hal+json"
+
+	halVoter = 400
+)
+
+type halLink struct {
+	Href string `json:"href"`
+}
+
+type halDocument struct {
+	Links    map[string]halLink                  `json:"_links"`
+	Embedded map[string][]map[string]interface{} `json:"_embedded"`
+}
+
+func Test_LoadHalData(t *testing.T) {
+	rsp, err := cli.R().SetBody(newRandVoter(halVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	vote := newRandVote(halVoter, 0)
+	vote.VoteValue = 1
+	rsp, err = cli.R().SetBody(vote).Post(fmt.Sprintf("%s/voters/%d/polls", BASE_API, halVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_HalHome(t *testing.T) {
+	var doc halDocument
+
+	rsp, err := cli.R().SetHeader("Accept", HAL_JSON).SetResult(&doc).Get(BASE_API + "/")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Contains(t, rsp.Header().Get("Content-Type"), HAL_JSON)
+
+	assert.Equal(t, "/", doc.Links["self"].Href)
+	assert.Equal(t, "/voters", doc.Links["voters"].Href)
+	assert.Equal(t, "/polls", doc.Links["polls"].Href)
+	assert.Equal(t, "/votes", doc.Links["votes"].Href)
+}
+
+func Test_HalVoter(t *testing.T) {
+	var doc halDocument
+
+	rsp, err := cli.R().SetHeader("Accept", HAL_JSON).SetResult(&doc).
+		Get(fmt.Sprintf("%s/voters/%d", BASE_API, halVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Contains(t, rsp.Header().Get("Content-Type"), HAL_JSON)
+
+	assert.Equal(t, fmt.Sprintf("/voters/%d", halVoter), doc.Links["self"].Href)
+	assert.Equal(t, fmt.Sprintf("/voters/%d/polls", halVoter), doc.Links["voter_history"].Href)
+
+	history := doc.Embedded["voter_history"]
+	assert.Equal(t, 1, len(history))
+
+	links := history[0]["_links"].(map[string]interface{})
+	poll := links["poll"].(map[string]interface{})
+	assert.Equal(t, "/polls/0", poll["href"])
+}
+
+func Test_HalVote(t *testing.T) {
+	var doc halDocument
+
+	rsp, err := cli.R().SetHeader("Accept", HAL_JSON).SetResult(&doc).Get(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, "/votes", doc.Links["self"].Href)
+
+	votes := doc.Embedded["votes"]
+	assert.Equal(t, 1, len(votes))
+
+	links := votes[0]["_links"].(map[string]interface{})
+	value := links["vote_value"].(map[string]interface{})
+	assert.Equal(t, "/polls/0/options/1", value["href"])
+}
+
+func Test_HalPoll(t *testing.T) {
+	var doc halDocument
+
+	rsp, err := cli.R().SetHeader("Accept", HAL_JSON).SetResult(&doc).Get(BASE_API + "/polls/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, "/polls/0", doc.Links["self"].Href)
+	assert.Equal(t, "/polls/0/options", doc.Links["poll_options"].Href)
+	assert.Equal(t, 5, len(doc.Embedded["poll_options"]))
+}
+
+func Test_PlainJsonIsDefault(t *testing.T) {
+	var doc map[string]interface{}
+
+	rsp, err := cli.R().SetResult(&doc).Get(fmt.Sprintf("%s/voters/%d", BASE_API, halVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Contains(t, rsp.Header().Get("Content-Type"), "application/json")
+
+	assert.NotContains(t, doc, "_links")
+	assert.Contains(t, doc, "voter_history")
+}
+
+func Test_CleanupHalData(t *testing.T) {
+	rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, halVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"drexel.edu/voter/api"
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type healthDocument struct {
+	Status       string `json:"status"`
+	Version      string `json:"version"`
+	Commit       string `json:"commit"`
+	Dependencies map[string]struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	} `json:"dependencies"`
+}
+
+// unreachableStore is a store whose backend cannot be reached
+type unreachableStore struct {
+	db.VoterStore
+}
+
+func (s unreachableStore) Ping(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func Test_HealthLive(t *testing.T) {
+	var doc healthDocument
+	rsp, err := cli.R().SetResult(&doc).Get(BASE_API + "/health/live")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, "ok", doc.Status)
+	assert.NotEmpty(t, doc.Version)
+	assert.NotEmpty(t, doc.Commit)
+}
+
+func Test_HealthReady(t *testing.T) {
+	var doc healthDocument
+	rsp, err := cli.R().SetResult(&doc).Get(BASE_API + "/health/ready")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, "ready", doc.Status)
+	assert.Equal(t, "up", doc.Dependencies["store"].Status)
+}
+
+func Test_HealthNotReady(t *testing.T) {
+	store, err := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, err)
+	handler := api.New(unreachableStore{store})
+
+	app := fiber.New()
+	app.Get("/health/live", handler.Live)
+	app.Get("/health/ready", handler.Ready)
+
+	rsp, err := app.Test(httptest.NewRequest(http.MethodGet, "/health/live", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, rsp.StatusCode, "expected the api to stay live without its store")
+
+	rsp, err = app.Test(httptest.NewRequest(http.MethodGet, "/health/ready", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 503, rsp.StatusCode)
+
+	var doc healthDocument
+	assert.NoError(t, json.NewDecoder(rsp.Body).Decode(&doc))
+	assert.Equal(t, "not ready", doc.Status)
+	assert.Equal(t, "down", doc.Dependencies["store"].Status)
+	assert.Equal(t, "connection refused", doc.Dependencies["store"].Error)
+}
+
+func Test_HealthDraining(t *testing.T) {
+	store, err := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, err)
+	handler := api.New(store)
+
+	app := fiber.New()
+	app.Get("/health/live", handler.Live)
+	app.Get("/health/ready", handler.Ready)
+
+	rsp, err := app.Test(httptest.NewRequest(http.MethodGet, "/health/ready", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, rsp.StatusCode)
+
+	handler.Drain()
+
+	rsp, err = app.Test(httptest.NewRequest(http.MethodGet, "/health/ready", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 503, rsp.StatusCode, "expected a draining api not to be ready")
+
+	var doc healthDocument
+	assert.NoError(t, json.NewDecoder(rsp.Body).Decode(&doc))
+	assert.Equal(t, "draining", doc.Status)
+
+	rsp, err = app.Test(httptest.NewRequest(http.MethodGet, "/health/live", nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, rsp.StatusCode, "expected a draining api to stay live")
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The integrity tests use their own voter and polls, and remove them when
+// they are done so the voter tests start from an empty database.
+const (
+	integrityVoter = 300
+	integrityPoll  = 300
+	missingPoll    = 399
+)
+
+func Test_LoadIntegrityData(t *testing.T) {
+	rsp, err := cli.R().SetBody(newRandVoter(integrityVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	poll := newRandPoll(integrityPoll)
+	poll.PollOptions = append(poll.PollOptions, newRandOption(0), newRandOption(1))
+
+	rsp, err = cli.R().SetBody(poll).Post(BASE_API + "/polls")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
+
+func Test_AddVoteForMissingPoll(t *testing.T) {
+	vote := newRandVoteResource(integrityVoter, missingPoll)
+
+	rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_AddVoteForMissingOption(t *testing.T) {
+	vote := newRandVoteResource(integrityVoter, integrityPoll)
+	vote.VoteValue = 2
+
+	rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_AddVoterPollForMissingPoll(t *testing.T) {
+	vote := newRandVote(integrityVoter, missingPoll)
+
+	rsp, err := cli.R().SetBody(vote).
+		Post(fmt.Sprintf("%s/voters/%d/polls", BASE_API, integrityVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_AddVoterWithVoteForMissingPoll(t *testing.T) {
+	voter := newRandVoter(integrityVoter + 1)
+	voter.VoteHistory = append(voter.VoteHistory, newRandVote(integrityVoter+1, missingPoll))
+
+	rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_DeleteOptionCascadesVotes(t *testing.T) {
+	vote := newRandVoteResource(integrityVoter, integrityPoll)
+	vote.VoteValue = 1
+
+	rsp, err := cli.R().SetBody(vote).SetResult(&vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Delete(fmt.Sprintf("%s/polls/%d/options/1", BASE_API, integrityPoll))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Get(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected vote to be removed with its option")
+}
+
+func Test_DeletePollCascadesVotes(t *testing.T) {
+	vote := newRandVoteResource(integrityVoter, integrityPoll)
+	vote.VoteValue = 0
+
+	rsp, err := cli.R().SetBody(vote).SetResult(&vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Delete(fmt.Sprintf("%s/polls/%d", BASE_API, integrityPoll))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Get(fmt.Sprintf("%s/voters/%d/polls/%d", BASE_API, integrityVoter, integrityPoll))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected vote to be removed with its poll")
+}
+
+func Test_AddVoteForDeletedPoll(t *testing.T) {
+	vote := newRandVoteResource(integrityVoter, integrityPoll)
+	vote.VoteValue = 0
+
+	rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+// Votes cast while their poll is deleted either lose, with a 409, or are
+// removed along with the poll.  None may be left behind for a poll that is
+// gone.
+func Test_VoteWhilePollIsDeleted(t *testing.T) {
+	for round := uint(0); round < 20; round++ {
+		pollId := integrityPoll + 10 + round
+		poll := newRandPoll(pollId)
+		poll.PollOptions = append(poll.PollOptions, newRandOption(0))
+		rsp, err := cli.R().SetBody(poll).Post(BASE_API + "/polls")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			vote := newRandVoteResource(integrityVoter, pollId)
+			vote.VoteValue = 0
+			rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+			assert.Nil(t, err)
+			assert.Contains(t, []int{200, 409}, rsp.StatusCode(), rsp.String())
+		}()
+		go func() {
+			defer wg.Done()
+			rsp, err := cli.R().Delete(fmt.Sprintf("%s/polls/%d", BASE_API, pollId))
+			assert.Nil(t, err)
+			assert.Equal(t, 200, rsp.StatusCode(), rsp.String())
+		}()
+		wg.Wait()
+
+		rsp, err = cli.R().Get(fmt.Sprintf("%s/voters/%d/polls/%d", BASE_API, integrityVoter, pollId))
+		assert.Nil(t, err)
+		assert.Equal(t, 404, rsp.StatusCode(), "expected no vote for deleted poll %d", pollId)
+	}
+}
+
+func Test_RestrictDeletePolicy(t *testing.T) {
+	ctx := context.Background()
+	store, err := db.NewMemoryVoterList(db.DeleteRestrict)
+	assert.NoError(t, err)
+
+	poll := newRandPoll(integrityPoll + 1)
+	poll.PollOptions = append(poll.PollOptions, newRandOption(0), newRandOption(1))
+	assert.NoError(t, store.AddPoll(ctx, poll))
+	_, err = store.AddVoter(ctx, newRandVoter(integrityVoter+1))
+	assert.NoError(t, err)
+
+	vote := newRandVoteResource(integrityVoter+1, integrityPoll+1)
+	vote.VoteValue = 1
+	vote, err = store.AddVote(ctx, vote)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, store.DeleteVoter(ctx, integrityVoter+1, db.AnyVersion), db.ErrConflict)
+	assert.ErrorIs(t, store.DeletePoll(ctx, integrityPoll+1), db.ErrConflict)
+
+	withoutOption, err := poll.DeleteOption(1)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.UpdatePoll(ctx, withoutOption), db.ErrConflict)
+
+	stored, err := store.GetPoll(ctx, integrityPoll+1)
+	assert.NoError(t, err)
+	assert.Equal(t, poll, stored, "expected poll to be unchanged")
+
+	assert.NoError(t, store.DeleteVote(ctx, vote.VoteId, db.AnyVersion))
+	assert.NoError(t, store.UpdatePoll(ctx, withoutOption))
+	assert.NoError(t, store.DeletePoll(ctx, integrityPoll+1))
+	assert.NoError(t, store.DeleteVoter(ctx, integrityVoter+1, db.AnyVersion))
+}
+
+func Test_CleanupIntegrityData(t *testing.T) {
+	rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, integrityVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"drexel.edu/voter/api"
+	"drexel.edu/voter/db"
+	"drexel.edu/voter/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLogs sends the JSON log lines to a buffer until the test is done
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, slog.LevelDebug)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line), "expected every line to be JSON")
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func Test_RequestIds(t *testing.T) {
+	rsp, err := cli.R().SetHeader("X-Request-ID", "test-request").Get(BASE_API + "/voters/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "test-request", rsp.Header().Get("X-Request-ID"), "expected the client's request id to be kept")
+
+	first, err := cli.R().Get(BASE_API + "/health/live")
+	assert.Nil(t, err)
+	second, err := cli.R().Get(BASE_API + "/health/live")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, first.Header().Get("X-Request-ID"))
+	assert.NotEqual(t, first.Header().Get("X-Request-ID"), second.Header().Get("X-Request-ID"))
+}
+
+func Test_RequestLogLines(t *testing.T) {
+	buf := captureLogs(t)
+
+	store, err := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, err)
+	handler := api.New(store)
+
+	app := fiber.New(fiber.Config{ErrorHandler: api.ErrorHandler})
+	app.Use(api.LogRequests)
+	app.Get("/voters/:id", handler.GetVoter)
+
+	req := httptest.NewRequest(http.MethodGet, "/voters/5", nil)
+	req.Header.Set("X-Request-ID", "log-request")
+	_, err = app.Test(req, -1)
+	assert.NoError(t, err)
+
+	lines := logLines(t, buf)
+	assert.Equal(t, 2, len(lines), "expected the handler's line and the request line")
+	for _, line := range lines {
+		assert.Equal(t, "log-request", line["request_id"])
+		assert.Equal(t, "/voters/:id", line["route"])
+		assert.Equal(t, float64(5), line["voter_id"])
+	}
+
+	request := lines[len(lines)-1]
+	assert.Equal(t, "Request", request["msg"])
+	assert.Equal(t, "WARN", request["level"])
+	assert.Equal(t, float64(404), request["status"])
+	assert.Contains(t, request, "latency_ms")
+}
+
+// The store gets a context that is cancelled along with the one given to
+// RequestContext, and still carries the request id LogRequests adds
+func Test_RequestContextCancels(t *testing.T) {
+	buf := captureLogs(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	app := fiber.New(fiber.Config{ErrorHandler: api.ErrorHandler})
+	app.Use(api.RequestContext(ctx))
+	app.Use(api.LogRequests)
+	app.Get("/", func(c *fiber.Ctx) error {
+		slog.InfoContext(c.UserContext(), "From the handler")
+		return c.SendString(fmt.Sprint(c.UserContext().Err()))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "cancelled-request")
+	rsp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, context.Canceled.Error(), string(body))
+
+	lines := logLines(t, buf)
+	assert.Equal(t, "From the handler", lines[0]["msg"])
+	assert.Equal(t, "cancelled-request", lines[0]["request_id"])
+}
+
+func Test_ContextLogAttributes(t *testing.T) {
+	buf := captureLogs(t)
+
+	ctx := logging.With(context.Background(), "request_id", "ctx-request")
+	ctx = logging.With(ctx, slog.Int("voter_id", 9))
+	slog.InfoContext(ctx, "From the db")
+
+	lines := logLines(t, buf)
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, "ctx-request", lines[0]["request_id"])
+	assert.Equal(t, float64(9), lines[0]["voter_id"])
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The paging tests use their own voters, starting at pageFirstVoter, and
+// remove them when they are done.
+const (
+	pageFirstVoter = 500
+	pageVoters     = 5
+)
+
+// nextLink pulls the url out of a Link header like </voters?cursor=2>; rel="next"
+func nextLink(header string) string {
+	target, rel, found := strings.Cut(header, ";")
+	if !found || strings.TrimSpace(rel) != `rel="next"` {
+		return ""
+	}
+	return strings.Trim(target, "<> ")
+}
+
+func Test_LoadPageVoters(t *testing.T) {
+	for i := uint(0); i < pageVoters; i++ {
+		rsp, err := cli.R().SetBody(newRandVoter(pageFirstVoter + i)).Post(BASE_API + "/voters")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_PageVoters(t *testing.T) {
+	var ids []uint
+
+	url := fmt.Sprintf("/voters?limit=2&cursor=%d", pageFirstVoter)
+	for pages := 0; url != ""; pages++ {
+		assert.Less(t, pages, pageVoters, "expected paging to finish")
+
+		var voters []db.Voter
+		rsp, err := cli.R().SetResult(&voters).Get(BASE_API + url)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+		assert.LessOrEqual(t, len(voters), 2)
+
+		for _, voter := range voters {
+			ids = append(ids, voter.VoterId)
+		}
+
+		url = nextLink(rsp.Header().Get("Link"))
+	}
+
+	assert.Equal(t, []uint{500, 501, 502, 503, 504}, ids)
+}
+
+func Test_PageVotersHal(t *testing.T) {
+	var doc halDocument
+
+	rsp, err := cli.R().SetHeader("Accept", HAL_JSON).SetResult(&doc).
+		Get(fmt.Sprintf("%s/voters?limit=3&cursor=%d", BASE_API, pageFirstVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, 3, len(doc.Embedded["voters"]))
+	assert.Equal(t, "/voters?limit=3&cursor=503", doc.Links["next"].Href)
+}
+
+func Test_PageVotersBadLimit(t *testing.T) {
+	rsp, err := cli.R().Get(BASE_API + "/voters?limit=-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+
+	rsp, err = cli.R().Get(BASE_API + "/voters?limit=5000")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+}
+
+func Test_CleanupPageVoters(t *testing.T) {
+	for i := uint(0); i < pageVoters; i++ {
+		rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, pageFirstVoter+i))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The patch tests use their own voter and remove it when they are done.
+const (
+	patchVoter = 800
+
+	MERGE_PATCH_JSON = "application/merge-patch+json"
+)
+
+var (
+	patchVote db.VoterHistory
+)
+
+func patchVoterUrl() string {
+	return fmt.Sprintf("%s/voters/%d", BASE_API, patchVoter)
+}
+
+func Test_LoadPatchData(t *testing.T) {
+	voter := newRandVoter(patchVoter)
+	voter.Name = "patch name"
+	voter.Email = "patch@place.com"
+
+	vote := newRandVote(patchVoter, 0)
+	vote.VoteValue = 1
+	voter.VoteHistory = append(voter.VoteHistory, vote)
+
+	var added db.Voter
+	rsp, err := cli.R().SetBody(voter).SetResult(&added).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	etag := rsp.Header().Get("ETag")
+
+	rsp, err = cli.R().SetResult(&patchVote).Get(patchVoterUrl() + "/polls/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	// The voter is sent back as it was stored, with the id of its vote
+	assert.Equal(t, rsp.Header().Get("ETag"), etag, "expected the ETag of the added voter")
+	assert.Equal(t, 1, len(added.VoteHistory))
+	assert.NotZero(t, patchVote.VoteId)
+	assert.Equal(t, patchVote.VoteId, added.VoteHistory[0].VoteId)
+}
+
+func Test_PatchVoterEmail(t *testing.T) {
+	var voter db.Voter
+
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"email": "new@place.com"}`).SetResult(&voter).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	etag := rsp.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rsp, err = cli.R().SetResult(&voter).Get(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, rsp.Header().Get("ETag"), etag, "expected the ETag of the patched voter")
+
+	assert.Equal(t, "new@place.com", voter.Email)
+	assert.Equal(t, "patch name", voter.Name, "expected fields not in the patch to be kept")
+	assert.Equal(t, 1, len(voter.VoteHistory), "expected the vote history to be kept")
+	assert.Equal(t, patchVote.VoteId, voter.VoteHistory[0].VoteId)
+}
+
+func Test_PatchVoterNullRemoves(t *testing.T) {
+	// Removing the name leaves a voter that fails validation
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"name": null}`).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+	assert.Contains(t, string(rsp.Body()), `"name":"name"`)
+
+	var voter db.Voter
+	rsp, err = cli.R().SetResult(&voter).Get(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, "patch name", voter.Name)
+}
+
+func Test_PatchVoterInvalid(t *testing.T) {
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"voter_id": 801}`).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+
+	// Two votes for the same poll fail ValidateVotes
+	history := fmt.Sprintf(`{"voter_history": [{"poll_id": 1, "voter_id": %d}, {"poll_id": 1, "voter_id": %d}]}`,
+		patchVoter, patchVoter)
+	rsp, err = cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(history).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("Content-Type", "text/plain").
+		SetBody(`{"email": "text@place.com"}`).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 415, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"email": "missing@place.com"}`).Patch(fmt.Sprintf("%s/voters/%d", BASE_API, patchVoter+1))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_PatchVoterIfMatch(t *testing.T) {
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).SetHeader("If-Match", `"999999"`).
+		SetBody(`{"email": "stale@place.com"}`).Patch(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 412, rsp.StatusCode())
+}
+
+func Test_PatchVoterPoll(t *testing.T) {
+	var vote db.VoterHistory
+
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"vote_value": 3}`).SetResult(&vote).Patch(patchVoterUrl() + "/polls/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, uint(3), vote.VoteValue)
+
+	rsp, err = cli.R().SetResult(&vote).Get(voteUrl(patchVote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, uint(3), vote.VoteValue)
+	assert.Equal(t, patchVote.VoteDate.Unix(), vote.VoteDate.Unix(), "expected the vote date to be kept")
+}
+
+func Test_PatchVoterPollInvalid(t *testing.T) {
+	rsp, err := cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"poll_id": 1}`).Patch(patchVoterUrl() + "/polls/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+
+	// The polls only have options 0 to 4
+	rsp, err = cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"vote_value": 9}`).Patch(patchVoterUrl() + "/polls/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+
+	rsp, err = cli.R().SetHeader("Content-Type", MERGE_PATCH_JSON).
+		SetBody(`{"vote_value": 1}`).Patch(patchVoterUrl() + "/polls/3")
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_CleanupPatchData(t *testing.T) {
+	rsp, err := cli.R().Delete(patchVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"testing"
+
+	"drexel.edu/voter/db"
+	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
+	"github.com/stretchr/testify/assert"
+)
+
+func newRandPoll(id uint) db.Poll {
+	return db.Poll{
+		PollId:       id,
+		PollTitle:    fake.Sentence(3),
+		PollQuestion: fake.Question(),
+		PollOptions:  make([]db.PollOption, 0),
+	}
+}
+
+func newRandOption(id uint) db.PollOption {
+	return db.PollOption{
+		PollOptionId:   id,
+		PollOptionText: fake.Word(),
+	}
+}
+
+func Test_LoadPolls(t *testing.T) {
+	numLoad := 3
+	for i := 100; i < 100+numLoad; i++ {
+		item := newRandPoll(uint(i))
+		rsp, err := cli.R().
+			SetBody(item).
+			Post(BASE_API + "/polls")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_LoadDuplicatePoll(t *testing.T) {
+	item := newRandPoll(101)
+
+	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/polls")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_LoadPollWithDuplicateOptions(t *testing.T) {
+	item := newRandPoll(104)
+	item.PollOptions = append(item.PollOptions, newRandOption(0), newRandOption(0))
+
+	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/polls")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+}
+
+func Test_GetPoll(t *testing.T) {
+	var item db.Poll
+
+	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/polls/100")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, uint(100), item.PollId)
+}
+
+func Test_GetMissingPoll(t *testing.T) {
+	rsp, err := cli.R().Get(BASE_API + "/polls/104")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_UpdatePoll(t *testing.T) {
+	var item, changedItem db.Poll
+
+	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/polls/101")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "poll #101 expected.")
+
+	item.PollQuestion = fake.Question()
+
+	rsp, err = cli.R().SetResult(&changedItem).SetBody(item).Put(BASE_API + "/polls/101")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "expected successful response")
+	assert.Equal(t, item, changedItem)
+
+	rsp, err = cli.R().SetResult(&changedItem).Get(BASE_API + "/polls/101")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "poll #101 expected.")
+	assert.Equal(t, item, changedItem)
+}
+
+func Test_UpdatePollWithWrongId(t *testing.T) {
+	item := newRandPoll(104)
+
+	rsp, err := cli.R().SetBody(item).Put(BASE_API + "/polls/101")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+}
+
+func Test_LoadOptions(t *testing.T) {
+	numLoad := 3
+	for i := 0; i < numLoad; i++ {
+		item := newRandOption(uint(i))
+		rsp, err := cli.R().
+			SetBody(item).
+			Post(BASE_API + "/polls/100/options")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_LoadDuplicateOption(t *testing.T) {
+	item := newRandOption(0)
+
+	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/polls/100/options")
+	assert.Nil(t, err)
+	assert.Equal(t, 409, rsp.StatusCode())
+}
+
+func Test_LoadOptionToMissingPoll(t *testing.T) {
+	item := newRandOption(0)
+
+	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/polls/104/options")
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_GetAllOptions(t *testing.T) {
+	var items []db.PollOption
+
+	rsp, err := cli.R().SetResult(&items).Get(BASE_API + "/polls/100/options")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, 3, len(items))
+}
+
+func Test_UpdateOption(t *testing.T) {
+	var option, changedOption db.PollOption
+
+	url := BASE_API + "/polls/100/options/1"
+
+	rsp, err := cli.R().SetResult(&option).Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "poll #100 with option #1 expected")
+
+	option.PollOptionText = fake.Word()
+
+	rsp, err = cli.R().SetBody(option).SetResult(&changedOption).Put(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "Expect successful change.")
+	assert.Equal(t, option, changedOption)
+
+	rsp, err = cli.R().SetResult(&changedOption).Get(url)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "poll #100 with option #1 expected")
+	assert.Equal(t, option, changedOption)
+}
+
+func Test_UpdateOptionWithWrongOptionId(t *testing.T) {
+	option := newRandOption(2)
+
+	rsp, err := cli.R().SetBody(option).Put(BASE_API + "/polls/100/options/0")
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+}
+
+func Test_UpdateMissingOption(t *testing.T) {
+	option := newRandOption(4)
+
+	rsp, err := cli.R().SetBody(option).Put(BASE_API + "/polls/100/options/4")
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
+
+func Test_DeleteOption(t *testing.T) {
+	rsp, err := cli.R().Delete(BASE_API + "/polls/100/options/2")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "option not deleted")
+
+	rsp, err = cli.R().Get(BASE_API + "/polls/100/options/2")
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected not found error code")
+
+	var poll db.Poll
+	rsp, err = cli.R().SetResult(&poll).Get(BASE_API + "/polls/100")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, 2, len(poll.PollOptions))
+}
+
+func Test_DeleteMissingOption(t *testing.T) {
+	rsp, err := cli.R().Delete(BASE_API + "/polls/100/options/4")
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
+
+func Test_DeletePoll(t *testing.T) {
+	rsp, err := cli.R().Delete(BASE_API + "/polls/102")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode(), "poll not deleted")
+
+	rsp, err = cli.R().Get(BASE_API + "/polls/102")
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected not found error code")
+}
+
+func Test_DeleteMissingPoll(t *testing.T) {
+	rsp, err := cli.R().Delete(BASE_API + "/polls/104")
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
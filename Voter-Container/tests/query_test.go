@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The query tests use their own voters and polls and remove them when they
+// are done.  Every query is limited to the voters of one of the polls, so
+// voters left by other tests cannot get in the way.
+const (
+	queryFirstVoter = 1200
+	queryPoll       = 1200
+	queryOtherPoll  = 1201
+	queryTiePoll    = 1202
+)
+
+type queryVote struct {
+	poll uint
+	date string
+}
+
+var queryVoters = []struct {
+	name  string
+	email string
+	votes []queryVote
+}{
+	{"Carol", "carol@query.org", []queryVote{{queryPoll, "2020-01-01"}}},
+	{"alice", "zed@query.org", []queryVote{{queryPoll, "2023-06-01"}}},
+	{"Bob", "bob@query.org", []queryVote{{queryPoll, "2022-03-01"}, {queryOtherPoll, "2021-01-01"}}},
+	{"Dave", "dave@query.org", []queryVote{{queryOtherPoll, "2024-01-01"}}},
+	{"Eve", "eve@query.org", []queryVote{{queryTiePoll, "2024-01-01"}}},
+	{"eve", "eve@other.query.org", []queryVote{{queryTiePoll, "2024-01-01"}}},
+}
+
+// queryVoterIds lists the voters for the query string, in the order they
+// are returned, along with the link to the next page
+func queryVoterIds(t *testing.T, query string) ([]uint, string) {
+	var voters []db.Voter
+	rsp, err := cli.R().SetResult(&voters).Get(BASE_API + "/voters?" + query)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	ids := make([]uint, 0, len(voters))
+	for _, voter := range voters {
+		ids = append(ids, voter.VoterId)
+	}
+	return ids, nextLink(rsp.Header().Get("Link"))
+}
+
+func Test_LoadQueryData(t *testing.T) {
+	for _, id := range []uint{queryPoll, queryOtherPoll, queryTiePoll} {
+		poll := newRandPoll(id)
+		poll.PollOptions = append(poll.PollOptions, newRandOption(0), newRandOption(1))
+
+		rsp, err := cli.R().SetBody(poll).Post(BASE_API + "/polls")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+
+	for i, item := range queryVoters {
+		voter := newRandVoter(queryFirstVoter + uint(i))
+		voter.Name = item.name
+		voter.Email = item.email
+		for _, vote := range item.votes {
+			history := newRandVote(voter.VoterId, vote.poll)
+			history.VoteDate, _ = time.Parse(time.DateOnly, vote.date)
+			voter.VoteHistory = append(voter.VoteHistory, history)
+		}
+
+		rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_QueryVotedIn(t *testing.T) {
+	ids, _ := queryVoterIds(t, fmt.Sprintf("voted_in=%d", queryOtherPoll))
+	assert.Equal(t, []uint{1202, 1203}, ids)
+}
+
+func Test_QueryVotedAfter(t *testing.T) {
+	ids, _ := queryVoterIds(t, fmt.Sprintf("voted_in=%d&voted_after=2022-01-01", queryPoll))
+	assert.Equal(t, []uint{1201, 1202}, ids)
+
+	ids, _ = queryVoterIds(t, fmt.Sprintf("voted_in=%d&voted_after=2023-01-01T00:00:00Z", queryOtherPoll))
+	assert.Equal(t, []uint{1203}, ids)
+}
+
+func Test_QuerySort(t *testing.T) {
+	ids, _ := queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=name", queryPoll))
+	assert.Equal(t, []uint{1201, 1202, 1200}, ids, "expected names to be sorted without case")
+
+	ids, _ = queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=-email", queryPoll))
+	assert.Equal(t, []uint{1201, 1200, 1202}, ids)
+
+	ids, _ = queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=-voter_id", queryPoll))
+	assert.Equal(t, []uint{1202, 1201, 1200}, ids)
+}
+
+func Test_QuerySortPages(t *testing.T) {
+	ids, next := queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=name&limit=2", queryPoll))
+	assert.Equal(t, []uint{1201, 1202}, ids)
+	assert.Equal(t, fmt.Sprintf("/voters?limit=2&sort=name&voted_in=%d&offset=2", queryPoll), next)
+
+	ids, next = queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=name&limit=2&offset=2", queryPoll))
+	assert.Equal(t, []uint{1200}, ids)
+	assert.Equal(t, "", next)
+}
+
+// Voters with the same name stay in voter id order, whichever way the names
+// are sorted, so paging through them one at a time gets each of them once
+func Test_QuerySortTies(t *testing.T) {
+	for _, sort := range []string{"name", "-name"} {
+		ids, next := queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=%s&limit=1", queryTiePoll, sort))
+		assert.Equal(t, []uint{1204}, ids)
+		assert.NotEqual(t, "", next)
+
+		ids, next = queryVoterIds(t, fmt.Sprintf("voted_in=%d&sort=%s&limit=1&offset=1", queryTiePoll, sort))
+		assert.Equal(t, []uint{1205}, ids)
+		assert.Equal(t, "", next)
+	}
+}
+
+func Test_QueryBadParameters(t *testing.T) {
+	for _, query := range []string{"sort=age", "voted_after=yesterday", "sort=name&cursor=1", "offset=1", "sort=name&offset=-1", "sort=name&offset=10000"} {
+		rsp, err := cli.R().Get(BASE_API + "/voters?" + query)
+		assert.Nil(t, err)
+		assert.Equal(t, 400, rsp.StatusCode(), "expected %q to be refused", query)
+	}
+}
+
+func Test_CleanupQueryData(t *testing.T) {
+	for i := range queryVoters {
+		rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, queryFirstVoter+uint(i)))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+
+	for _, id := range []uint{queryPoll, queryOtherPoll, queryTiePoll} {
+		rsp, err := cli.R().Delete(fmt.Sprintf("%s/polls/%d", BASE_API, id))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
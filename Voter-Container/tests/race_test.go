@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"drexel.edu/voter/api"
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// The race tests run the in-memory store and the api in process, so that
+// go test -race can see every goroutine touching them.
+const (
+	raceVoters = 20
+	racePolls  = 4
+)
+
+func newRaceStore(t *testing.T) *db.MemoryVoterList {
+	ctx := context.Background()
+	store, err := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, err)
+
+	for i := uint(0); i < racePolls; i++ {
+		poll := newRandPoll(i)
+		poll.PollOptions = append(poll.PollOptions, newRandOption(0), newRandOption(1))
+		assert.NoError(t, store.AddPoll(ctx, poll))
+	}
+	return store
+}
+
+func Test_MemoryStoreConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	store := newRaceStore(t)
+
+	concurrently(raceVoters, func(i int) {
+		id := uint(i + 1)
+		_, err := store.AddVoter(ctx, newRandVoter(id))
+		assert.NoError(t, err)
+
+		for poll := uint(0); poll < racePolls; poll++ {
+			vote := newRandVoteResource(id, poll)
+			vote.VoteValue = 0
+
+			vote, err := store.AddVote(ctx, vote)
+			assert.NoError(t, err)
+
+			vote.VoteValue = 1
+			_, err = store.UpdateVote(ctx, vote)
+			assert.NoError(t, err)
+		}
+
+		_, err = store.GetAllVoters(ctx)
+		assert.NoError(t, err)
+		_, err = store.GetVoterPage(ctx, db.VoterQuery{Limit: 5})
+		assert.NoError(t, err)
+		_, err = store.GetAllVotes(ctx)
+		assert.NoError(t, err)
+
+		poll, err := store.GetPoll(ctx, id%racePolls)
+		assert.NoError(t, err)
+		poll.PollTitle = fmt.Sprintf("title %d", i)
+		assert.NoError(t, store.UpdatePoll(ctx, poll))
+	})
+
+	votes, err := store.GetAllVotes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, raceVoters*racePolls, len(votes))
+
+	concurrently(len(votes), func(i int) {
+		assert.NoError(t, store.DeleteVote(ctx, votes[i].VoteId, db.AnyVersion))
+	})
+
+	votes, err = store.GetAllVotes(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(votes))
+}
+
+func Test_StatsConcurrentUse(t *testing.T) {
+	handler := api.New(newRaceStore(t))
+
+	app := fiber.New()
+	app.Use("/voters", handler.HandleStats)
+	app.Post("/voters", handler.AddVoter)
+	app.Get("/voters/:id", handler.GetVoter)
+	app.Get("/metrics", handler.Metrics)
+
+	concurrently(raceVoters, func(i int) {
+		voter := newRandVoter(uint(i + 1))
+		voter.VoteHistory = append(voter.VoteHistory, newRandVote(voter.VoterId, 1))
+		body, err := json.Marshal(voter)
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/voters", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		rsp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, rsp.StatusCode)
+
+		// Each goroutine also asks for a voter id past the ones the test adds,
+		// which is never created, so the 404s are counted too
+		rsp, err = app.Test(httptest.NewRequest(http.MethodGet, fmt.Sprintf("/voters/%d", i+raceVoters+1), nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 404, rsp.StatusCode)
+
+		rsp, err = app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 200, rsp.StatusCode)
+	})
+
+	rsp, err := app.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil), -1)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(rsp.Body)
+	assert.NoError(t, err)
+
+	metrics := string(body)
+	assert.Contains(t, metrics, fmt.Sprintf(`voter_api_http_requests_total{method="POST",route="/voters",status="200"} %d`, raceVoters))
+	assert.Contains(t, metrics, fmt.Sprintf(`voter_api_http_requests_total{method="GET",route="/voters/:id",status="404"} %d`, raceVoters))
+	assert.Contains(t, metrics, fmt.Sprintf(`voter_api_http_request_duration_seconds_count{method="GET",route="/voters/:id"} %d`, raceVoters))
+	assert.Contains(t, metrics, fmt.Sprintf(`voter_api_votes_cast_total{poll="1"} %d`, raceVoters))
+}
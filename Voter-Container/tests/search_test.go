@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The search tests use their own voters, with names and emails no other
+// test uses, and remove them when they are done.
+const (
+	searchFirstVoter = 1100
+)
+
+var searchVoters = []struct {
+	name  string
+	email string
+}{
+	{"Quinton Zephyr", "qzephyr@search.org"},
+	{"Zelda Quarry", "zelda_q@search.org"},
+	{"Quinn Zephyrine", "quinn@other.org"},
+}
+
+func searchFor(t *testing.T, q string) []uint {
+	var voters []db.Voter
+	rsp, err := cli.R().SetQueryParam("q", q).SetResult(&voters).Get(BASE_API + "/voters/search")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	ids := make([]uint, 0, len(voters))
+	for _, voter := range voters {
+		ids = append(ids, voter.VoterId)
+	}
+	return ids
+}
+
+func Test_LoadSearchData(t *testing.T) {
+	for i, item := range searchVoters {
+		voter := newRandVoter(searchFirstVoter + uint(i))
+		voter.Name = item.name
+		voter.Email = item.email
+
+		rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_SearchVoters(t *testing.T) {
+	assert.Equal(t, []uint{1100, 1102}, searchFor(t, "zephyr"), "expected a word to match the start of longer words")
+	assert.Equal(t, []uint{1100, 1102}, searchFor(t, "QUIN"), "expected the search to ignore case")
+	assert.Equal(t, []uint{1100, 1101}, searchFor(t, "search.org"), "expected emails to be searched")
+	assert.Equal(t, []uint{1101}, searchFor(t, "zelda_q"))
+	assert.Equal(t, []uint{1102}, searchFor(t, "quinn other"), "expected every word to have to match")
+	assert.Equal(t, []uint{}, searchFor(t, "nobody"))
+}
+
+func Test_SearchVotersLimit(t *testing.T) {
+	var voters []db.Voter
+	rsp, err := cli.R().SetResult(&voters).Get(BASE_API + "/voters/search?q=zephyr&limit=1")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, 1, len(voters))
+}
+
+func Test_SearchVotersBadQuery(t *testing.T) {
+	for _, q := range []string{"", "a", "..."} {
+		rsp, err := cli.R().SetQueryParam("q", q).Get(BASE_API + "/voters/search")
+		assert.Nil(t, err)
+		assert.Equal(t, 400, rsp.StatusCode(), "expected %q to be refused", q)
+	}
+}
+
+func Test_CleanupSearchData(t *testing.T) {
+	for i := range searchVoters {
+		rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, searchFirstVoter+uint(i)))
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
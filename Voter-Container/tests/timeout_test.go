@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"drexel.edu/voter/api"
+	"drexel.edu/voter/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore is a store whose reads of voters fail with err
+type failingStore struct {
+	db.VoterStore
+	err error
+}
+
+func (s failingStore) GetVoter(ctx context.Context, id uint) (db.Voter, error) {
+	return db.Voter{}, s.err
+}
+
+func (s failingStore) GetVoterByEmail(ctx context.Context, email string) (db.Voter, error) {
+	return db.Voter{}, s.err
+}
+
+func failingApp(t *testing.T, err error) *fiber.App {
+	store, storeErr := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, storeErr)
+	handler := api.New(failingStore{VoterStore: store, err: err})
+
+	app := fiber.New(fiber.Config{ErrorHandler: api.ErrorHandler})
+	app.Get("/voters", handler.ListAllVoters)
+	app.Get("/voters/:id", handler.GetVoter)
+	return app
+}
+
+func Test_StoreTimeoutStatus(t *testing.T) {
+	app := failingApp(t, fmt.Errorf("%w: %w", db.ErrTimeout, context.DeadlineExceeded))
+
+	for _, url := range []string{"/voters/1", "/voters?email=slow@place.com"} {
+		rsp, err := app.Test(httptest.NewRequest(http.MethodGet, url, nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 504, rsp.StatusCode, url)
+	}
+}
+
+func Test_StoreUnavailableStatus(t *testing.T) {
+	app := failingApp(t, fmt.Errorf("%w: connection refused", db.ErrUnavailable))
+
+	for _, url := range []string{"/voters/1", "/voters?email=gone@place.com"} {
+		rsp, err := app.Test(httptest.NewRequest(http.MethodGet, url, nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 503, rsp.StatusCode, url)
+	}
+}
+
+func Test_RedisUnavailable(t *testing.T) {
+	// Nothing listens on the port once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	_, err = db.NewWithCacheInstance(address, db.DeleteCascade, db.DefaultTimeouts)
+	assert.ErrorIs(t, err, db.ErrUnavailable)
+}
+
+func Test_RedisTimeout(t *testing.T) {
+	// The listener takes connections but never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	start := time.Now()
+	_, err = db.NewWithCacheInstance(listener.Addr().String(), db.DeleteCascade,
+		db.Timeouts{Read: 100 * time.Millisecond, Write: 100 * time.Millisecond, Bulk: 100 * time.Millisecond})
+	assert.ErrorIs(t, err, db.ErrTimeout)
+	assert.Less(t, time.Since(start), 2*time.Second, "expected the timeout rather than the client's own")
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"drexel.edu/voter/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// The validation tests use their own voter and remove it when they are done.
+const (
+	validationVoter = 900
+
+	PROBLEM_JSON = "application/problem+json"
+)
+
+type problemDocument struct {
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Instance      string `json:"instance"`
+	InvalidParams []struct {
+		Name   string `json:"name"`
+		Reason string `json:"reason"`
+	} `json:"invalid-params"`
+}
+
+func validationVoterUrl() string {
+	return fmt.Sprintf("%s/voters/%d", BASE_API, validationVoter)
+}
+
+// invalidParams checks that body is a 400 problem and returns the names
+// of the fields it lists
+func invalidParams(t *testing.T, status int, contentType string, body []byte) []string {
+	assert.Equal(t, 400, status)
+	assert.Equal(t, PROBLEM_JSON, contentType)
+
+	var doc problemDocument
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, 400, doc.Status)
+	assert.Equal(t, "Bad Request", doc.Title)
+
+	names := make([]string, 0, len(doc.InvalidParams))
+	for _, param := range doc.InvalidParams {
+		assert.NotEmpty(t, param.Reason)
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+func Test_AddVoterInvalidFields(t *testing.T) {
+	voter := db.Voter{VoterId: validationVoter, Email: "not-an-email"}
+
+	rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	names := invalidParams(t, rsp.StatusCode(), rsp.Header().Get("Content-Type"), rsp.Body())
+	assert.ElementsMatch(t, []string{"name", "email"}, names)
+
+	voter = newRandVoter(0)
+	rsp, err = cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	names = invalidParams(t, rsp.StatusCode(), rsp.Header().Get("Content-Type"), rsp.Body())
+	assert.ElementsMatch(t, []string{"voter_id"}, names)
+
+	rsp, err = cli.R().Get(validationVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode(), "expected invalid voters not to be stored")
+}
+
+func Test_AddVoterFutureVote(t *testing.T) {
+	voter := newRandVoter(validationVoter)
+	vote := newRandVote(validationVoter, 0)
+	vote.VoteDate = time.Now().Add(24 * time.Hour)
+	voter.VoteHistory = append(voter.VoteHistory, vote)
+
+	rsp, err := cli.R().SetBody(voter).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	names := invalidParams(t, rsp.StatusCode(), rsp.Header().Get("Content-Type"), rsp.Body())
+	assert.ElementsMatch(t, []string{"voter_history[0].vote_date"}, names)
+}
+
+func Test_UpdateVoterInvalidFields(t *testing.T) {
+	rsp, err := cli.R().SetBody(newRandVoter(validationVoter)).Post(BASE_API + "/voters")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	voter := newRandVoter(validationVoter)
+	voter.Name = ""
+	rsp, err = cli.R().SetBody(voter).Put(validationVoterUrl())
+	assert.Nil(t, err)
+	names := invalidParams(t, rsp.StatusCode(), rsp.Header().Get("Content-Type"), rsp.Body())
+	assert.ElementsMatch(t, []string{"name"}, names)
+
+	vote := newRandVote(validationVoter, 0)
+	vote.VoteDate = time.Now().Add(24 * time.Hour)
+	rsp, err = cli.R().SetBody(vote).Post(validationVoterUrl() + "/polls")
+	assert.Nil(t, err)
+	names = invalidParams(t, rsp.StatusCode(), rsp.Header().Get("Content-Type"), rsp.Body())
+	assert.ElementsMatch(t, []string{"vote_date"}, names)
+}
+
+func Test_ProblemForMissingVoter(t *testing.T) {
+	var doc problemDocument
+
+	url := fmt.Sprintf("/voters/%d", validationVoter+1)
+	rsp, err := cli.R().SetResult(&doc).SetError(&doc).Get(BASE_API + url)
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+	assert.Equal(t, PROBLEM_JSON, rsp.Header().Get("Content-Type"))
+	assert.Equal(t, 404, doc.Status)
+	assert.Equal(t, url, doc.Instance)
+}
+
+func Test_CleanupValidationData(t *testing.T) {
+	rsp, err := cli.R().Delete(validationVoterUrl())
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+}
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"drexel.edu/voter/db"
+	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
+	"github.com/stretchr/testify/assert"
+)
+
+// The vote tests use their own voters so that they can clean up after
+// themselves before the voter tests run against an empty database.
+const (
+	voteTestVoter      = 200
+	voteTestOtherVoter = 201
+)
+
+var (
+	castVote db.Vote
+)
+
+func newRandVoteResource(voter_id uint, poll_id uint) db.Vote {
+	return db.Vote{
+		VoterId:   voter_id,
+		PollId:    poll_id,
+		VoteValue: uint(fake.Number(0, 3)),
+		VoteDate:  fake.PastDate(),
+	}
+}
+
+func voteUrl(id uint) string {
+	return fmt.Sprintf("%s/votes/%d", BASE_API, id)
+}
+
+func Test_LoadVoteVoters(t *testing.T) {
+	for _, id := range []uint{voteTestVoter, voteTestOtherVoter} {
+		rsp, err := cli.R().SetBody(newRandVoter(id)).Post(BASE_API + "/voters")
+
+		assert.Nil(t, err)
+		assert.Equal(t, 200, rsp.StatusCode())
+	}
+}
+
+func Test_AddVote(t *testing.T) {
+	vote := newRandVoteResource(voteTestVoter, 1)
+
+	rsp, err := cli.R().SetBody(vote).SetResult(&castVote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.NotZero(t, castVote.VoteId, "expected a vote id to be assigned")
+	assert.Equal(t, vote.PollId, castVote.PollId)
+	assert.Equal(t, vote.VoteValue, castVote.VoteValue)
+}
+
+func Test_AddVoteShowsInVoterHistory(t *testing.T) {
+	var history db.VoterHistory
+
+	rsp, err := cli.R().SetResult(&history).
+		Get(fmt.Sprintf("%s/voters/%d/polls/1", BASE_API, voteTestVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, castVote.VoteId, history.VoteId)
+	assert.Equal(t, castVote.VoteValue, history.VoteValue)
+}
+
+func Test_AddVoterPollShowsInVotes(t *testing.T) {
+	var history db.VoterHistory
+	var vote db.Vote
+
+	item := newRandVote(voteTestOtherVoter, 3)
+	item.VoteValue = 2
+
+	rsp, err := cli.R().SetBody(item).SetResult(&history).
+		Post(fmt.Sprintf("%s/voters/%d/polls", BASE_API, voteTestOtherVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.NotZero(t, history.VoteId, "expected a vote id to be assigned")
+
+	rsp, err = cli.R().SetResult(&vote).Get(voteUrl(history.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, history.ToVote(), vote)
+}
+
+func Test_AddVoteForMissingVoter(t *testing.T) {
+	vote := newRandVoteResource(4, 1)
+
+	rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
+
+func Test_AddDuplicatePollVote(t *testing.T) {
+	vote := newRandVoteResource(voteTestVoter, 1)
+
+	rsp, err := cli.R().SetBody(vote).Post(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
+
+// A vote without an id is not given the id a later vote in the same history
+// already has, even though that id is not in use yet
+func Test_NewVoteIdsSkipIdsInHistory(t *testing.T) {
+	ctx := context.Background()
+	store, err := db.NewMemoryVoterList(db.DeleteCascade)
+	assert.NoError(t, err)
+
+	for _, id := range []uint{1, 2} {
+		poll := newRandPoll(id)
+		poll.PollOptions = append(poll.PollOptions, newRandOption(0))
+		assert.NoError(t, store.AddPoll(ctx, poll))
+	}
+
+	// The store has given out no ids, so 1 is the next one
+	voter := newRandVoter(voteTestVoter)
+	voter.VoteHistory = append(voter.VoteHistory, newRandVote(voter.VoterId, 1), newRandVote(voter.VoterId, 2))
+	voter.VoteHistory[1].VoteId = 1
+
+	stored, err := store.AddVoter(ctx, voter)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), stored.VoteHistory[1].VoteId)
+	assert.NotZero(t, stored.VoteHistory[0].VoteId)
+	assert.NotEqual(t, uint(1), stored.VoteHistory[0].VoteId, "expected the new vote to get an id of its own")
+}
+
+func Test_GetAllVotesResource(t *testing.T) {
+	var votes []db.Vote
+
+	rsp, err := cli.R().SetResult(&votes).Get(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	assert.Equal(t, 2, len(votes))
+}
+
+func Test_GetMissingVote(t *testing.T) {
+	rsp, err := cli.R().Get(voteUrl(castVote.VoteId + 1000))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_UpdateVoteResource(t *testing.T) {
+	var changedVote db.Vote
+	var history db.VoterHistory
+
+	vote := castVote
+	vote.VoteValue = vote.VoteValue + 1
+
+	rsp, err := cli.R().SetBody(vote).SetResult(&changedVote).Put(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, vote.VoteValue, changedVote.VoteValue)
+
+	rsp, err = cli.R().SetResult(&history).
+		Get(fmt.Sprintf("%s/voters/%d/polls/1", BASE_API, voteTestVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, vote.VoteValue, history.VoteValue)
+}
+
+func Test_UpdateVoteResourceWithWrongId(t *testing.T) {
+	vote := castVote
+
+	rsp, err := cli.R().SetBody(vote).Put(voteUrl(vote.VoteId + 1000))
+	assert.Nil(t, err)
+	assert.Equal(t, 400, rsp.StatusCode())
+}
+
+func Test_UpdateVoteResourceToOtherVoter(t *testing.T) {
+	vote := castVote
+	vote.VoterId = voteTestOtherVoter
+
+	rsp, err := cli.R().SetBody(vote).Put(voteUrl(vote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 500, rsp.StatusCode())
+}
+
+func Test_DeleteVoteResource(t *testing.T) {
+	rsp, err := cli.R().Delete(voteUrl(castVote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Get(voteUrl(castVote.VoteId))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+
+	rsp, err = cli.R().Get(fmt.Sprintf("%s/voters/%d/polls/1", BASE_API, voteTestVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 404, rsp.StatusCode())
+}
+
+func Test_DeleteVoterRemovesVotes(t *testing.T) {
+	var votes []db.Vote
+
+	rsp, err := cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, voteTestOtherVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().Delete(fmt.Sprintf("%s/voters/%d", BASE_API, voteTestVoter))
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+
+	rsp, err = cli.R().SetResult(&votes).Get(BASE_API + "/votes")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, rsp.StatusCode())
+	assert.Equal(t, 0, len(votes))
+}
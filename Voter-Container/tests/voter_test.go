@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"testing"
@@ -28,6 +29,29 @@ func TestMain(m *testing.M) {
 		os.Exit(1)
 	}
 
+	rsp, err = cli.R().Delete(BASE_API + "/polls")
+
+	if rsp.StatusCode() != 200 {
+		log.Printf("error clearing polls, %v", err)
+		os.Exit(1)
+	}
+
+	//Votes must be for an option of an existing poll, so load a few
+	//polls for the vote tests to use
+	for i := 0; i < 4; i++ {
+		poll := newRandPoll(uint(i))
+		for j := 0; j < 5; j++ {
+			poll.PollOptions = append(poll.PollOptions, newRandOption(uint(j)))
+		}
+
+		rsp, err = cli.R().SetBody(poll).Post(BASE_API + "/polls")
+
+		if rsp.StatusCode() != 200 {
+			log.Printf("error loading polls, %v", err)
+			os.Exit(1)
+		}
+	}
+
 	code := m.Run()
 
 	//CLEANUP
@@ -40,7 +64,7 @@ func newRandVoter(id uint) db.Voter {
 	return db.Voter{
 		VoterId:     id,
 		Name:        fake.RandomString([]string{"name1", "name2", "name3"}),
-		Email:       fake.RandomString([]string{"name1@place.com", "name2@place.com", "name3@place.com"}),
+		Email:       fmt.Sprintf("voter%d@place.com", id), // every voter needs an email of its own
 		VoteHistory: make([]db.VoterHistory, 0),
 	}
 }
@@ -49,13 +73,13 @@ func newRandVote(voter_id uint, poll_id uint) db.VoterHistory {
 	return db.VoterHistory{
 		VoterId:  voter_id,
 		PollId:   poll_id,
-		VoteDate: fake.Date(),
+		VoteDate: fake.PastDate(),
 	}
 }
 
 func Test_LoadDB(t *testing.T) {
 	numLoad := 3
-	for i := 0; i < numLoad; i++ {
+	for i := 1; i <= numLoad; i++ {
 		item := newRandVoter(uint(i))
 		rsp, err := cli.R().
 			SetBody(item).
@@ -165,13 +189,13 @@ func Test_UpdateVoterWithDuplicatePolls(t *testing.T) {
 
 	var item db.Voter
 
-	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/voters/0")
+	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/voters/3")
 	assert.Nil(t, err)
-	assert.Equal(t, 200, rsp.StatusCode(), "voter #1 expected.")
+	assert.Equal(t, 200, rsp.StatusCode(), "voter #3 expected.")
 
-	item.VoteHistory = append(item.VoteHistory, newRandVote(2, 0), newRandVote(2, 0))
+	item.VoteHistory = append(item.VoteHistory, newRandVote(3, 0), newRandVote(3, 0))
 
-	rsp, err = cli.R().SetBody(item).Put(BASE_API + "/voters/0")
+	rsp, err = cli.R().SetBody(item).Put(BASE_API + "/voters/3")
 	assert.Nil(t, err)
 	assert.Equal(t, 400, rsp.StatusCode())
 }
@@ -180,13 +204,13 @@ func Test_UpdateVoterWithWrongVoterInVotes(t *testing.T) {
 
 	var item db.Voter
 
-	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/voters/0")
+	rsp, err := cli.R().SetResult(&item).Get(BASE_API + "/voters/3")
 	assert.Nil(t, err)
-	assert.Equal(t, 200, rsp.StatusCode(), "voter #1 expected.")
+	assert.Equal(t, 200, rsp.StatusCode(), "voter #3 expected.")
 
-	item.VoteHistory = append(item.VoteHistory, newRandVote(3, 0))
+	item.VoteHistory = append(item.VoteHistory, newRandVote(2, 0))
 
-	rsp, err = cli.R().SetBody(item).Put(BASE_API + "/voters/0")
+	rsp, err = cli.R().SetBody(item).Put(BASE_API + "/voters/3")
 	assert.Nil(t, err)
 	assert.Equal(t, 400, rsp.StatusCode())
 }
@@ -215,7 +239,7 @@ func Test_LoadDuplicateVote(t *testing.T) {
 func Test_LoadVoteToWrongVoter(t *testing.T) {
 	item := newRandVote(1, 0)
 
-	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/voters/0/polls")
+	rsp, err := cli.R().SetBody(item).Post(BASE_API + "/voters/3/polls")
 	assert.Nil(t, err)
 	assert.Equal(t, 400, rsp.StatusCode())
 
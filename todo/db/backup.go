@@ -0,0 +1,151 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBackupCount is how many backups a ToDo keeps unless told otherwise
+const DefaultBackupCount = 5
+
+// backupTimeFormat is how a backup's time is written in its file name.  It
+// is in UTC and fixed width, so the names sort in time order.
+const backupTimeFormat = "20060102T150405.000000000Z"
+
+// Backup is a copy of the database as it was just before a change.  Every
+// change takes one, and only the newest ones are kept.
+type Backup struct {
+	// Stamp names the backup, it is the time the backup was taken as it
+	// appears in the file name
+	Stamp    string
+	Time     time.Time
+	FileName string
+}
+
+// SetBackupCount sets how many backups are kept.  It is DefaultBackupCount
+// unless set, and 0 turns backups off.
+func (t *ToDo) SetBackupCount(count int) {
+	t.backupCount = count
+}
+
+// backupPrefix starts the file name of every backup, which is the
+// database file name followed by .backup- and the time
+func (t *ToDo) backupPrefix() string {
+	return filepath.Base(t.dbFileName) + ".backup-"
+}
+
+// Backups returns the backups of the database, newest first
+func (t *ToDo) Backups() ([]Backup, error) {
+	dir := filepath.Dir(t.dbFileName)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	prefix := t.backupPrefix()
+	for _, entry := range entries {
+		stamp, found := strings.CutPrefix(entry.Name(), prefix)
+		if !found || entry.IsDir() {
+			continue
+		}
+		//Temporary files of backups being written don't parse, and are
+		//skipped along with anything else that isn't a backup
+		taken, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{
+			Stamp:    stamp,
+			Time:     taken,
+			FileName: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// RestoreBackup copies the backup over the database.  Like any other
+// change, the database as it was is backed up first, so the restore can
+// be undone.  The id counter is not set back to the backup's, so the ids
+// given out since the backup are not given out again.
+func (t *ToDo) RestoreBackup(backup Backup) error {
+	return t.restoreFrom(backup.FileName)
+}
+
+// restoreFrom replaces the items in the database with the ones in the
+// file while holding the lock, keeping the larger of the two id counters
+func (t *ToDo) restoreFrom(fileName string) error {
+	unlock, err := t.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	//A database that doesn't load is what a restore is for, so it only
+	//stops its counter from being kept
+	nextId := 0
+	if err := t.loadDB(); err == nil {
+		nextId = t.nextId
+	}
+
+	if err := t.parseDB(data); err != nil {
+		return fmt.Errorf("%s is not a todo database: %w", fileName, err)
+	}
+	t.nextId = max(t.nextId, nextId)
+
+	//The backup is of the file, which still holds the database as it was
+	if err := t.backup(); err != nil {
+		return err
+	}
+	return t.saveDB()
+}
+
+// backup copies the database file, as it is before a change, to a new
+// backup and deletes the oldest ones past the backup count.  The caller
+// holds the lock.
+func (t *ToDo) backup() error {
+	if t.backupCount <= 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(t.dbFileName)
+	if err != nil {
+		return fmt.Errorf("backing up the database: %w", err)
+	}
+	backups, err := t.Backups()
+	if err != nil {
+		return fmt.Errorf("backing up the database: %w", err)
+	}
+
+	//Keep the stamps unique and in order even if the clock is coarse or
+	//has gone back
+	taken := time.Now().UTC()
+	if len(backups) > 0 && !taken.After(backups[0].Time) {
+		taken = backups[0].Time.Add(time.Nanosecond)
+	}
+	fileName := filepath.Join(filepath.Dir(t.dbFileName), t.backupPrefix()+taken.Format(backupTimeFormat))
+	if err := writeFileAtomic(fileName, data, 0644); err != nil {
+		return fmt.Errorf("backing up the database: %w", err)
+	}
+
+	//The new backup takes one of the places
+	for _, old := range backups[min(len(backups), t.backupCount-1):] {
+		if err := os.Remove(old.FileName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("removing an old backup: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLockTimeout is how long a ToDo waits for another todo process to
+// let go of the database before giving up
+const DefaultLockTimeout = 5 * time.Second
+
+// lockPollInterval is how often a held lock is tried again
+const lockPollInterval = 20 * time.Millisecond
+
+// ErrLocked is returned when the database stays locked by another todo
+// process for longer than the lock timeout
+var ErrLocked = errors.New("database is locked")
+
+// errWouldBlock is returned by tryLock when someone else holds the lock
+var errWouldBlock = errors.New("lock is held")
+
+// lockFileName is the file the advisory lock is taken on.  It can't be the
+// database file itself, that is replaced by a new file on every save.
+func (t *ToDo) lockFileName() string {
+	return t.dbFileName + ".lock"
+}
+
+// lock takes the advisory lock on the database, waiting up to the lock
+// timeout for another todo process to release it.  The returned function
+// releases the lock.
+func (t *ToDo) lock() (func() error, error) {
+	deadline := time.Now().Add(t.lockTimeout)
+	for {
+		unlock, err := tryLock(t.lockFileName())
+		if !errors.Is(err, errWouldBlock) {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s is held by another todo process, gave up after %s",
+				ErrLocked, t.lockFileName(), t.lockTimeout)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// writeFileAtomic replaces the file with data so that it is never seen
+// half written, not even after a crash.  The data is written to a
+// temporary file in the same directory and flushed to disk, then renamed
+// over the file, and finally the directory is flushed so the rename sticks.
+func writeFileAtomic(fileName string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(fileName)
+	f, err := os.CreateTemp(dir, filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+
+	//Clean up the temporary file if anything goes wrong before the rename
+	renamed := false
+	defer func() {
+		if !renamed {
+			f.Close()
+			os.Remove(tmpName)
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, fileName); err != nil {
+		return err
+	}
+	renamed = true
+
+	return syncDir(dir)
+}
@@ -0,0 +1,40 @@
+//go:build unix
+
+package db
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryLock takes an exclusive flock on the lock file without waiting.  The
+// kernel drops the lock when the file is closed, or when the process dies,
+// so a crashed todo never leaves the database locked.
+func tryLock(lockFileName string) (func() error, error) {
+	f, err := os.OpenFile(lockFileName, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errWouldBlock
+		}
+		return nil, err
+	}
+
+	return f.Close, nil
+}
+
+// syncDir flushes the directory to disk, which makes a rename in it durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -1,24 +1,55 @@
 package db
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"time"
 )
 
-// ToDoItem is the struct that represents a single ToDo item
+// ToDoItem is the struct that represents a single ToDo item.  Only
+// Id, Title and IsDone are always there, the other fields are left
+// out of the database file when they are not set, so files written
+// before they were added still load.
 type ToDoItem struct {
 	Id     int    `json:"id"`
 	Title  string `json:"title"`
 	IsDone bool   `json:"done"`
+
+	// Due is when the item has to be done by
+	Due *time.Time `json:"due,omitempty"`
+
+	// Priority goes from 1, the most urgent, to MaxPriority.  0 means
+	// the item has no priority.
+	Priority int `json:"priority,omitempty"`
+
+	Tags  []string `json:"tags,omitempty"`
+	Notes string   `json:"notes,omitempty"`
+
+	// CreatedAt is set when the item is added, and CompletedAt when it
+	// is marked done
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
+// MaxPriority is the least urgent priority an item can have
+const MaxPriority = 5
+
 // DbMap is a type alias for a map of ToDoItems.  The key
 // will be the ToDoItem.Id and the value will be the ToDoItem
 type DbMap map[int]ToDoItem
 
+// dbContents is how the database file is laid out.  NextId is the id the
+// next item added without one gets.  It only ever goes up, so the ids of
+// deleted items are not handed out again.  Files written before the
+// counter was added hold just the array of items.
+type dbContents struct {
+	NextId int        `json:"next_id"`
+	Items  []ToDoItem `json:"items"`
+}
+
 // ToDo is the struct that represents the main object of our
 // todo app.  It contains a map of ToDoItems and the name of
 // the file that is used to store the items.
@@ -34,9 +65,18 @@ type DbMap map[int]ToDoItem
 //		package. This allows us to safely change the implementation
 //	 without breaking the users of the package. For example changing
 //	 to use an actual database instead of a file.
+//
+// Several todo processes can share the database file.  Each change holds
+// an advisory lock on the file from loading it to saving it, so no change
+// is lost, and saves replace the file in one step, so a crash never leaves
+// it half written.  The database as it was before each change is kept in
+// a few rotating backups.
 type ToDo struct {
-	toDoMap    DbMap
-	dbFileName string
+	toDoMap     DbMap
+	nextId      int
+	dbFileName  string
+	lockTimeout time.Duration
+	backupCount int
 }
 
 // New is a constructor function that returns a pointer to a new
@@ -45,6 +85,12 @@ type ToDo struct {
 // If the file doesn't exist, it will be created.  If the file
 // does exist, it will be loaded into the ToDo struct.
 func New(dbFile string) (*ToDo, error) {
+	toDo := &ToDo{
+		toDoMap:     make(map[int]ToDoItem),
+		dbFileName:  dbFile,
+		lockTimeout: DefaultLockTimeout,
+		backupCount: DefaultBackupCount,
+	}
 
 	//Check if the database file exists, if not use initDB to create it
 	//In go, you use the os.Stat function to get information about a file
@@ -52,24 +98,24 @@ func New(dbFile string) (*ToDo, error) {
 	//error we can safely assume that this file does not exist.
 	if _, err := os.Stat(dbFile); err != nil {
 		//If the file doesn't exist, create it
-		err := initDB(dbFile)
+		err := toDo.initDB()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	//Now that we know the file exists, at at the minimum we have
-	//a valid empty DB, lets create the ToDo struct
-	toDo := &ToDo{
-		toDoMap:    make(map[int]ToDoItem),
-		dbFileName: dbFile,
-	}
-
 	// We should be all set here, the ToDo struct is ready to go
 	// so we can support the public database operations
 	return toDo, nil
 }
 
+// SetLockTimeout sets how long changes wait for another todo process to
+// release the database before failing with ErrLocked.  It is
+// DefaultLockTimeout unless set.
+func (t *ToDo) SetLockTimeout(timeout time.Duration) {
+	t.lockTimeout = timeout
+}
+
 // RestoreDB copies the backup file to the db file. This is useful for testing
 // as we restore the database to a known state before running tests - or if we
 // mess up.  In the source code I provided there is a /data directory.  In that
@@ -85,7 +131,12 @@ func New(dbFile string) (*ToDo, error) {
 // Postcondition: The backup file will be copied to a file named todo.json
 // in the ./data directory.  Note this should overwrite the
 // existing todo.json file if it exists, or create it if it
-// does not exist.
+// does not exist.  The database as it was is backed up first,
+// and the id counter is not set back, so no id is handed out
+// twice.
+//
+// The todo.json.bak file is made by hand, it is not one of the
+// backups every change takes, see RestoreBackup for those.
 func (t *ToDo) RestoreDB() error {
 	//Copy the backup file to the db file
 	dbFileName := t.dbFileName
@@ -110,27 +161,20 @@ func (t *ToDo) RestoreDB() error {
 	// TODO: Implement this function
 	fmt.Println("DB File:", dbFileName)
 	fmt.Println("Backup DB File:", backupFileName)
-	backupFile, err := os.Open(backupFileName)
-	if err != nil {
-		return err
-	}
-	defer backupFile.Close()
-
-	dbFile, err := os.Create(dbFileName)
-	if err != nil {
-		return err
-	}
-	defer dbFile.Close()
 
-	_, err = io.Copy(dbFile, backupFile)
-	return err
+	//Like any other change, the copy waits for other todo processes and
+	//replaces the db file in one step
+	return t.restoreFrom(backupFileName)
 }
 
 //------------------------------------------------------------
 // THESE ARE THE PUBLIC FUNCTIONS THAT SUPPORT OUR TODO APP
 //------------------------------------------------------------
 
-// AddItem accepts a ToDoItem and adds it to the DB.
+// AddItem accepts a ToDoItem and adds it to the DB.  An item with an
+// Id of 0 gets the next free id.  The id of the added item is returned.
+// Unless the item has them already, CreatedAt is set to now, and so
+// is CompletedAt if the item is added done.
 // Preconditions:   (1) The database file must exist and be a valid
 //
 //					(2) The item must not already exist in the DB
@@ -143,7 +187,7 @@ func (t *ToDo) RestoreDB() error {
 //	 (1) The item will be added to the DB
 //		(2) The DB file will be saved with the item added
 //		(3) If there is an error, it will be returned
-func (t *ToDo) AddItem(item ToDoItem) error {
+func (t *ToDo) AddItem(item ToDoItem) (int, error) {
 	//TODO: Implement this function
 	//Start by loading the database into the private map in our struct
 	//see the loadDB() helper.  Then make sure the item we want to load
@@ -156,17 +200,33 @@ func (t *ToDo) AddItem(item ToDoItem) error {
 	//at the end to indicate that the item was properly added to the
 	//database.
 
-	if err := t.loadDB(); err != nil {
-		return err
+	now := stamp()
+	if item.CreatedAt == nil {
+		item.CreatedAt = &now
 	}
-
-	if _, exists := t.toDoMap[item.Id]; exists {
-		return errors.New("Item id already exists in db")
+	if item.IsDone && item.CompletedAt == nil {
+		item.CompletedAt = &now
 	}
 
-	t.toDoMap[item.Id] = item
+	err := t.change(func() error {
+		if item.Id == 0 {
+			item.Id = t.nextId
+		}
+		if _, exists := t.toDoMap[item.Id]; exists {
+			return errors.New("Item id already exists in db")
+		}
 
-	return t.saveDB()
+		t.toDoMap[item.Id] = item
+		if item.Id >= t.nextId {
+			t.nextId = item.Id + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return item.Id, nil
 }
 
 // DeleteItem accepts an item id and removes it from the DB.
@@ -195,17 +255,14 @@ func (t *ToDo) DeleteItem(id int) error {
 	//return nil at the end to indicate that the item was properly deleted
 	//from the database.
 
-	if err := t.loadDB(); err != nil {
-		return err
-	}
-
-	if _, exists := t.toDoMap[id]; !exists {
-		return errors.New("Item id does not exist in db")
-	}
-
-	delete(t.toDoMap, id)
+	return t.change(func() error {
+		if _, exists := t.toDoMap[id]; !exists {
+			return errors.New("Item id does not exist in db")
+		}
 
-	return t.saveDB()
+		delete(t.toDoMap, id)
+		return nil
+	})
 }
 
 // UpdateItem accepts a ToDoItem and updates it in the DB.
@@ -234,17 +291,33 @@ func (t *ToDo) UpdateItem(item ToDoItem) error {
 	//no errors, this function should return nil at the end to indicate
 	//that the item was properly updated in the database.
 
-	if err := t.loadDB(); err != nil {
-		return err
-	}
+	return t.change(func() error {
+		if _, exists := t.toDoMap[item.Id]; !exists {
+			return errors.New("Item id does not exist in db")
+		}
 
-	if _, exists := t.toDoMap[item.Id]; !exists {
-		return errors.New("Item id does not exist in db")
-	}
+		t.toDoMap[item.Id] = item
+		return nil
+	})
+}
 
-	t.toDoMap[item.Id] = item
+// UpdateItemFields changes the item with the id through edit, which is
+// given the item as it is in the DB.  Unlike a GetItem followed by an
+// UpdateItem, the item is read and written back under one lock, so a
+// change another todo process makes in between is not lost.  edit cannot
+// change the item's id.
+func (t *ToDo) UpdateItemFields(id int, edit func(item *ToDoItem)) error {
+	return t.change(func() error {
+		item, exists := t.toDoMap[id]
+		if !exists {
+			return errors.New("Item id does not exist in db")
+		}
 
-	return t.saveDB()
+		edit(&item)
+		item.Id = id
+		t.toDoMap[id] = item
+		return nil
+	})
 }
 
 // GetItem accepts an item id and returns the item from the DB.
@@ -351,8 +424,10 @@ func (t *ToDo) JsonToItem(jsonString string) (ToDoItem, error) {
 }
 
 // ChangeItemDoneStatus accepts an item id and a boolean status.
-// It returns an error if the status could not be updated for any
-// reason.  For example, the item itself does not exist, or an
+// Marking an item done sets its CompletedAt to now, marking it not
+// done clears it.  An item that is already done keeps the time it
+// was completed.  It returns an error if the status could not be
+// updated for any reason.  For example, the item itself does not exist, or an
 // IO error trying to save the updated status.
 
 // Preconditions:   (1) The database file must exist and be a valid
@@ -366,10 +441,8 @@ func (t *ToDo) JsonToItem(jsonString string) (ToDoItem, error) {
 //
 //	 (1) The items status in the database will be updated
 //		(2) If there is an error, it will be returned.
-//		(3) This function MUST use existing functionality for most of its
-//			work.  For example, it should call GetItem() to get the item
-//			from the DB, then it should call UpdateItem() to update the
-//			item in the DB (after the status is changed).
+//		(3) The item is read and written back under one lock, so a
+//			change another todo process makes in between is not lost.
 func (t *ToDo) ChangeItemDoneStatus(id int, value bool) error {
 	//TODO: Implement this function for EXTRA CREDIT if you want
 	//This function builds on all of the other functions you have
@@ -379,43 +452,77 @@ func (t *ToDo) ChangeItemDoneStatus(id int, value bool) error {
 	//errors along the way, return them.  If everything is successful
 	//return nil at the end to indicate that the item was properly
 
-	item, err := t.GetItem(id)
-	if err != nil {
-		return err
-	}
+	return t.change(func() error {
+		item, exists := t.toDoMap[id]
+		if !exists {
+			return errors.New("Id not found in db.")
+		}
 
-	item.IsDone = value
+		switch {
+		case value && !item.IsDone:
+			now := stamp()
+			item.CompletedAt = &now
+		case !value:
+			item.CompletedAt = nil
+		}
 
-	return t.UpdateItem(item)
+		item.IsDone = value
+		t.toDoMap[id] = item
+		return nil
+	})
 }
 
 //------------------------------------------------------------
 // THESE ARE HELPER FUNCTIONS THAT ARE NOT EXPORTED AKA PRIVATE
 //------------------------------------------------------------
 
+// stamp is the time CreatedAt and CompletedAt are set to, to the
+// second, as nobody needs more in a todo list
+func stamp() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
 // initDB is a helper function that creates a new file with an
-// empty json array.  This is used to make sure that the DB
-// file exists for operations on our ToDo struct.  This function
-// should be called by the New() function if the DB file doesn't
-// exist.  Notice this function does not have a receiver as its
-// used by New() to create the DB file
-func initDB(dbFileName string) error {
-	f, err := os.Create(dbFileName)
+// empty DB.  This is used to make sure that the DB file exists
+// for operations on our ToDo struct.  This function should be
+// called by the New() function if the DB file doesn't exist.
+func (t *ToDo) initDB() error {
+	unlock, err := t.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock()
+
+	//Another todo process may have created it while we waited
+	if _, err := os.Stat(t.dbFileName); err == nil {
+		return nil
+	}
 
-	// Given we are working with a json array as our DB structure
-	// we should initialize the file with an empty array, which
-	// in json is represented as "[]
-	_, err = f.Write([]byte("[]"))
+	// An empty DB has no items, and the first item added gets id 1
+	return writeFileAtomic(t.dbFileName, []byte(`{"next_id": 1, "items": []}`), 0644)
+}
+
+// change runs one load-modify-save cycle.  The lock is held from before
+// the load until after the save, so no other todo process can change the
+// database in between.  modify changes t.toDoMap, which is only backed up
+// and saved if it succeeds.
+func (t *ToDo) change(modify func() error) error {
+	unlock, err := t.lock()
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	f.Close()
-
-	return nil
+	if err := t.loadDB(); err != nil {
+		return err
+	}
+	if err := modify(); err != nil {
+		return err
+	}
+	if err := t.backup(); err != nil {
+		return err
+	}
+	return t.saveDB()
 }
 
 func (t *ToDo) saveDB() error {
@@ -424,20 +531,21 @@ func (t *ToDo) saveDB() error {
 	//3. Write the json to our file
 
 	//1. Convert our map into a slice
-	var toDoList []ToDoItem
+	toDoList := make([]ToDoItem, 0, len(t.toDoMap))
 	for _, item := range t.toDoMap {
 		toDoList = append(toDoList, item)
 	}
 
-	//2. Marshal the slice into json, lets pretty print it, but
-	//   this is not required
-	data, err := json.MarshalIndent(toDoList, "", "  ")
+	//2. Marshal the slice, along with the id counter, into json,
+	//   lets pretty print it, but this is not required
+	data, err := json.MarshalIndent(dbContents{NextId: t.nextId, Items: toDoList}, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	//3. Write the json to our file
-	err = os.WriteFile(t.dbFileName, data, 0644)
+	//3. Write the json to our file, through a temporary file so a
+	//   crash leaves either the old or the new database
+	err = writeFileAtomic(t.dbFileName, data, 0644)
 	if err != nil {
 		return err
 	}
@@ -451,16 +559,36 @@ func (t *ToDo) loadDB() error {
 		return err
 	}
 
-	//Now let's unmarshal the data into our map
-	var toDoList []ToDoItem
-	err = json.Unmarshal(data, &toDoList)
+	return t.parseDB(data)
+}
+
+// parseDB replaces the items and the id counter with the ones in data,
+// the contents of a database file
+func (t *ToDo) parseDB(data []byte) error {
+	//Now let's unmarshal the data into our map, older files are
+	//just the array of items
+	var err error
+	var contents dbContents
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &contents.Items)
+	} else {
+		err = json.Unmarshal(data, &contents)
+	}
 	if err != nil {
 		return err
 	}
 
-	//Now let's iterate over our slice and add each item to our map
-	for _, item := range toDoList {
+	//Now let's iterate over our slice and add each item to a fresh map,
+	//so items another todo process deleted don't linger.  The counter
+	//is kept ahead of every id in use, which also sets it for older
+	//files.
+	t.toDoMap = make(DbMap, len(contents.Items))
+	t.nextId = max(contents.NextId, 1)
+	for _, item := range contents.Items {
 		t.toDoMap[item.Id] = item
+		if item.Id >= t.nextId {
+			t.nextId = item.Id + 1
+		}
 	}
 
 	return nil
@@ -3,10 +3,13 @@ module drexel.edu/todo
 go 1.21
 
 require (
-	github.com/brianvoe/gofakeit/v6 v6.26.3 // indirect
+	github.com/brianvoe/gofakeit/v6 v6.26.3
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/testify v1.8.4 // indirect
 	golang.org/x/mod v0.14.0 // indirect
 	golang.org/x/tools v0.17.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -5,232 +5,556 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"drexel.edu/todo/db"
 )
 
-// Global variables to hold the command line flags to drive the todo CLI
-// application
+// Global variables to hold the command line flags shared by every
+// command of the todo CLI application
 var (
-	dbFileNameFlag string
-	restoreDbFlag  bool
-	listFlag       bool
-	itemStatusFlag bool
-	queryFlag      int
-	addFlag        string
-	updateFlag     string
-	deleteFlag     int
+	dbFileNameFlag  string
+	lockTimeoutFlag time.Duration
+	backupCountFlag int
 )
 
-type AppOptType int
-
-// To make the code a little more clean we will use the following
-// constants as basically enumerations for different options.  This
-// allows us to use a switch statement in main to process the command line
-// flags effectively
-const (
-	LIST_DB_ITEM AppOptType = iota
-	RESTORE_DB_ITEM
-	QUERY_DB_ITEM
-	ADD_DB_ITEM
-	UPDATE_DB_ITEM
-	DELETE_DB_ITEM
-	CHANGE_ITEM_STATUS
-	NOT_IMPLEMENTED
-	INVALID_APP_OPT
-)
+// errBadUsage is returned by a command that was called wrong, once the
+// problem and the command's usage have been printed
+var errBadUsage = errors.New("bad usage")
 
-// processCmdLineFlags parses the command line flags for our CLI
-//
-// TODO: This function uses the flag package to parse the command line
-//		 flags.  The flag package is not very flexible and can lead to
-//		 some confusing code.
-
-//					 REQUIRED:     Study the code below, and make sure you understand
-//								   how it works.  Go online and readup on how the
-//								   flag package works.  Then, write a nice comment
-//						  		   block to document this function that highights that
-//								   you understand how it works.
-//
-//					 EXTRA CREDIT: The best CLI and command line processor for
-//								   go is called Cobra.  Refactor this function to
-//								   use it.  See github.com/spf13/cobra for information
-//								   on how to use it.
-//
-//			 YOUR ANSWER: Sets up all of the flag options, placing the argument associated
-//		               the flag into the variable for that flag.
-//		               If no flags are set it returns an error.
-//		               Then it looks at every flag that was passed in in lexographic order
-//		               to determine the operation that is being requested. Only the last
-//	                command in the alphabet will be respected.
-//		               If no valid option is provided, then the usage is printed and an
-//		               error is returned.
-func processCmdLineFlags() (AppOptType, error) {
-	flag.StringVar(&dbFileNameFlag, "db", "./data/todo.json", "Name of the database file")
-	flag.BoolVar(&restoreDbFlag, "restore", false, "Restore the database from the backup file")
-	flag.BoolVar(&listFlag, "l", false, "List all the items in the database")
-	flag.IntVar(&queryFlag, "q", 0, "Query an item in the database")
-	flag.StringVar(&addFlag, "a", "", "Add an item to the database")
-	flag.StringVar(&updateFlag, "u", "", "Update an item in the database")
-	flag.IntVar(&deleteFlag, "d", 0, "Delete an item from the database")
-	flag.BoolVar(&itemStatusFlag, "s", false, "Change item 'done' status to true or false")
+// command is one of the things the todo CLI does, like list or add.  Each
+// command parses its own arguments with its own flag.FlagSet, so it has its
+// own flags, help text and validation.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	flag.Parse()
+var commands = []command{
+	{"list", "List the items in the database", runList},
+	{"show", "Show an item", runShow},
+	{"add", "Add an item to the database", runAdd},
+	{"update", "Change the title or other fields of an item", runUpdate},
+	{"done", "Mark an item as done, or not done with -undo", runDone},
+	{"rm", "Delete an item from the database", runRemove},
+	{"backups", "List the backups taken before each change", runBackups},
+	{"restore", "Restore the database from the backup file or a backup", runRestore},
+}
+
+// usage prints the commands and the flags they all share
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: todo [-db file] <command> [flags] [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run todo help <command> for the flags of a command.")
+}
+
+// newFlagSet creates the flag set of a command.  args describes the
+// command's flags and arguments on its usage line.
+func newFlagSet(name string, args string, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: todo [-db file] %s %s\n\n%s\n", name, args, summary)
 
-	var appOpt AppOptType = INVALID_APP_OPT
-
-	//show help if no flags are set
-	if len(os.Args) == 1 {
-		flag.Usage()
-		return appOpt, errors.New("no flags were set")
-	}
-
-	// Loop over the flags and check which ones are set, set appOpt
-	// accordingly
-	flag.Visit(func(f *flag.Flag) {
-		switch f.Name {
-		case "l":
-			appOpt = LIST_DB_ITEM
-		case "restore":
-			appOpt = RESTORE_DB_ITEM
-		case "q":
-			appOpt = QUERY_DB_ITEM
-		case "a":
-			appOpt = ADD_DB_ITEM
-		case "u":
-			appOpt = UPDATE_DB_ITEM
-		case "d":
-			appOpt = DELETE_DB_ITEM
-
-		//TODO: EXTRA CREDIT - Implment the -s flag that changes the
-		//done status of an item in the database.  For example -s=true
-		//will set the done status for a particular item to true, and
-		//-s=false will set the done states for a particular item to
-		//false.
-		//
-		//HINT FOR EXTRA CREDIT
-		//Note the -s option also requires an id for the item to that
-		//you want to change.  I recommend you use the -q option to
-		//specify the item id.  Therefore, the -s option is only valid
-		//if the -q option is also set
-		case "s":
-			//For extra credit you will need to change some things here
-			//and also in main under the CHANGE_ITEM_STATUS case
-			if appOpt == QUERY_DB_ITEM {
-				appOpt = CHANGE_ITEM_STATUS
-			} else {
-				appOpt = INVALID_APP_OPT
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out)
+			fmt.Fprintln(out, "Flags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseArgs parses the command's flags and returns its other arguments.
+// Unlike fs.Parse, flags may come after the arguments, so todo done 3
+// -undo works as well as todo done -undo 3.  Everything after -- is an
+// argument.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
 			}
-		default:
-			appOpt = INVALID_APP_OPT
+			// The flag set has printed the problem and the usage
+			return nil, errBadUsage
 		}
-	})
 
-	if appOpt == INVALID_APP_OPT || appOpt == NOT_IMPLEMENTED {
-		fmt.Println("Invalid option set or the desired option is not currently implemented")
-		flag.Usage()
-		return appOpt, errors.New("no flags or unimplemented were set")
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
 	}
+}
 
-	return appOpt, nil
+// usageError prints what is wrong with how the command was called,
+// followed by its usage
+func usageError(fs *flag.FlagSet, format string, a ...interface{}) error {
+	fmt.Fprintf(fs.Output(), "Error: %s\n", fmt.Sprintf(format, a...))
+	fs.Usage()
+	return errBadUsage
 }
 
-// main is the entry point for our todo CLI application.  It processes
-// the command line flags and then uses the db package to perform the
-// requested operation
-func main() {
+// parseId reads the single argument of a command that takes an item id
+func parseId(fs *flag.FlagSet, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, usageError(fs, "expected one item id, got %d arguments", len(args))
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, usageError(fs, "%q is not an item id", args[0])
+	}
+	return id, nil
+}
+
+// itemFlags are the flags add and update share, for the fields of an item
+// other than its title
+type itemFlags struct {
+	due      *string
+	priority *int
+	tags     *string
+	notes    *string
+}
 
-	//Process the command line flags
-	opts, err := processCmdLineFlags()
+func newItemFlags(fs *flag.FlagSet) itemFlags {
+	return itemFlags{
+		due:      fs.String("due", "", "When the item is due, as 2006-01-02, 2006-01-02 15:04 or RFC 3339"),
+		priority: fs.Int("priority", 0, fmt.Sprintf("The priority, from 1, the most urgent, to %d", db.MaxPriority)),
+		tags:     fs.String("tags", "", "The tags, separated by commas"),
+		notes:    fs.String("notes", "", "Notes on the item"),
+	}
+}
+
+// isSet reports whether the flag was given
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
+
+// parse checks the flags that were given and returns the function that
+// sets their fields on an item.  An empty value, or a priority of 0,
+// clears the field.
+func (f itemFlags) parse(fs *flag.FlagSet) (func(item *db.ToDoItem), error) {
+	due, err := parseDue(*f.due)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, usageError(fs, "%v", err)
 	}
+	if *f.priority < 0 || *f.priority > db.MaxPriority {
+		return nil, usageError(fs, "-priority must be from 1 to %d, or 0 for none", db.MaxPriority)
+	}
+	tags := parseTags(*f.tags)
+	notes := strings.TrimSpace(*f.notes)
 
-	//Create a new db object
+	return func(item *db.ToDoItem) {
+		if isSet(fs, "due") {
+			item.Due = due
+		}
+		if isSet(fs, "priority") {
+			item.Priority = *f.priority
+		}
+		if isSet(fs, "tags") {
+			item.Tags = tags
+		}
+		if isSet(fs, "notes") {
+			item.Notes = notes
+		}
+	}, nil
+}
+
+// parseDue reads a due date.  Dates and times without a zone are local.
+func parseDue(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, "2006-01-02 15:04", time.RFC3339} {
+		if due, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return &due, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a date, use 2006-01-02, 2006-01-02 15:04 or RFC 3339", value)
+}
+
+// parseTags splits a comma separated list of tags, dropping blank and
+// repeated ones
+func parseTags(value string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func openDB() (*db.ToDo, error) {
 	todo, err := db.New(dbFileNameFlag)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, err
+	}
+	todo.SetLockTimeout(lockTimeoutFlag)
+	todo.SetBackupCount(backupCountFlag)
+	return todo, nil
+}
+
+func runList(args []string) error {
+	fs := newFlagSet("list", "[-done | -open]", "List the items in the database, in id order.")
+	doneFlag := fs.Bool("done", false, "Only list the items that are done")
+	openFlag := fs.Bool("open", false, "Only list the items that are not done")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageError(fs, "list takes no arguments")
+	}
+	if *doneFlag && *openFlag {
+		return usageError(fs, "-done and -open cannot be used together")
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	items, err := todo.GetAllItems()
+	if err != nil {
+		return err
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Id < items[j].Id })
+
+	listed := 0
+	for _, item := range items {
+		if (*doneFlag && !item.IsDone) || (*openFlag && item.IsDone) {
+			continue
+		}
+		todo.PrintItem(item)
+		listed++
+	}
+	fmt.Println("THERE ARE", listed, "ITEMS IN THE DB")
+	return nil
+}
+
+func runShow(args []string) error {
+	fs := newFlagSet("show", "<id>", "Show the item with the id.")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseId(fs, rest)
+	if err != nil {
+		return err
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	item, err := todo.GetItem(id)
+	if err != nil {
+		return err
+	}
+	todo.PrintItem(item)
+	return nil
+}
+
+func runAdd(args []string) error {
+	fs := newFlagSet("add", "[-id <id>] [-done] [-due <date>] [-priority <n>] [-tags <tags>] [-notes <notes>] <title>",
+		"Add an item with the title, which may be given as several words.  The id of the new item is printed.")
+	idFlag := fs.Int("id", 0, "The id of the new item, which must not be in use, instead of the next free one")
+	doneFlag := fs.Bool("done", false, "Add the item as done")
+	fields := newItemFlags(fs)
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(strings.Join(rest, " "))
+	if title == "" {
+		return usageError(fs, "add needs a title")
+	}
+	if *idFlag < 0 {
+		return usageError(fs, "-id must be greater than 0")
+	}
+	setFields, err := fields.parse(fs)
+	if err != nil {
+		return err
+	}
+	item := db.ToDoItem{Id: *idFlag, Title: title, IsDone: *doneFlag}
+	setFields(&item)
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	id, err := todo.AddItem(item)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Added item", id)
+	return nil
+}
+
+func runUpdate(args []string) error {
+	fs := newFlagSet("update", "<id> [-title <title>] [-due <date>] [-priority <n>] [-tags <tags>] [-notes <notes>]",
+		"Change the fields of the item with the id that flags are given for.  An empty -due, -tags or -notes, or a -priority of 0, clears the field.")
+	titleFlag := fs.String("title", "", "The new title")
+	fields := newItemFlags(fs)
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseId(fs, rest)
+	if err != nil {
+		return err
+	}
+	if fs.NFlag() == 0 {
+		return usageError(fs, "update needs a -title or another field to change")
+	}
+
+	setFields, err := fields.parse(fs)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(*titleFlag)
+	if isSet(fs, "title") && title == "" {
+		return usageError(fs, "the title cannot be empty")
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	err = todo.UpdateItemFields(id, func(item *db.ToDoItem) {
+		if isSet(fs, "title") {
+			item.Title = title
+		}
+		setFields(item)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println("Updated item", id)
+	return nil
+}
+
+func runDone(args []string) error {
+	fs := newFlagSet("done", "[-undo] <id>", "Mark the item with the id as done.")
+	undoFlag := fs.Bool("undo", false, "Mark the item as not done instead")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseId(fs, rest)
+	if err != nil {
+		return err
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	if err := todo.ChangeItemDoneStatus(id, !*undoFlag); err != nil {
+		return err
+	}
+	if *undoFlag {
+		fmt.Println("Item", id, "is not done")
+	} else {
+		fmt.Println("Item", id, "is done")
+	}
+	return nil
+}
+
+func runRemove(args []string) error {
+	fs := newFlagSet("rm", "<id>", "Delete the item with the id.")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseId(fs, rest)
+	if err != nil {
+		return err
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	if err := todo.DeleteItem(id); err != nil {
+		return err
+	}
+	fmt.Println("Deleted item", id)
+	return nil
+}
+
+func runBackups(args []string) error {
+	fs := newFlagSet("backups", "",
+		"List the backups of the database, newest first.  One is taken before every change, and -backups of them are kept.")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageError(fs, "backups takes no arguments")
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+	backups, err := todo.Backups()
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		fmt.Printf("%3d  %s  %s\n", i+1, backup.Stamp, backup.Time.Local().Format("2006-01-02 15:04:05 MST"))
+	}
+	fmt.Println("THERE ARE", len(backups), "BACKUPS")
+	return nil
+}
+
+// findBackup picks the backup named by its number in the list todo backups
+// prints, or by its stamp
+func findBackup(backups []db.Backup, from string) (db.Backup, error) {
+	if n, err := strconv.Atoi(from); err == nil {
+		if n < 1 || n > len(backups) {
+			return db.Backup{}, fmt.Errorf("there is no backup %d, there are %d", n, len(backups))
+		}
+		return backups[n-1], nil
 	}
 
-	//Switch over the command line flags and call the appropriate
-	//function in the db package
-	switch opts {
-	case RESTORE_DB_ITEM:
-		fmt.Println("Running RESTORE_DB_ITEM...")
+	for _, backup := range backups {
+		if backup.Stamp == from {
+			return backup, nil
+		}
+	}
+	return db.Backup{}, fmt.Errorf("there is no backup %s", from)
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore", "[-from <n | stamp>]",
+		"Copy the backup file, the database file name followed by .bak, over the database.  With -from the backup listed by todo backups is restored instead.")
+	fromFlag := fs.String("from", "", "The number or stamp of the backup to restore, as todo backups lists them")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return usageError(fs, "restore takes no arguments")
+	}
+
+	todo, err := openDB()
+	if err != nil {
+		return err
+	}
+
+	if *fromFlag == "" {
 		if err := todo.RestoreDB(); err != nil {
-			fmt.Println("Error: ", err)
-			break
+			return err
 		}
 		fmt.Println("Database restored from backup file")
-	case LIST_DB_ITEM:
-		fmt.Println("Running QUERY_DB_ITEM...")
-		todoList, err := todo.GetAllItems()
-		if err != nil {
-			fmt.Println("Error: ", err)
-			break
-		}
-		for _, item := range todoList {
-			todo.PrintItem(item)
-		}
-		fmt.Println("THERE ARE", len(todoList), "ITEMS IN THE DB")
-		fmt.Println("Ok")
-
-	case QUERY_DB_ITEM:
-		fmt.Println("Running QUERY_DB_ITEM...")
-		item, err := todo.GetItem(queryFlag)
-		if err != nil {
-			fmt.Println("Error: ", err)
-			break
+		return nil
+	}
+
+	backups, err := todo.Backups()
+	if err != nil {
+		return err
+	}
+	backup, err := findBackup(backups, *fromFlag)
+	if err != nil {
+		return err
+	}
+	if err := todo.RestoreBackup(backup); err != nil {
+		return err
+	}
+	fmt.Println("Database restored from backup", backup.Stamp)
+	return nil
+}
+
+// runCommand runs the command named by the first argument, or prints the
+// help of one for todo help <command>
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errBadUsage
+	}
+
+	name, args := args[0], args[1:]
+	if name == "help" {
+		if len(args) == 0 {
+			usage()
+			return nil
 		}
-		todo.PrintItem(item)
-		fmt.Println("Ok")
-	case ADD_DB_ITEM:
-		fmt.Println("Running ADD_DB_ITEM...")
-		item, err := todo.JsonToItem(addFlag)
-		if err != nil {
-			fmt.Println("Add option requires a valid JSON todo item string")
-			fmt.Println("Error: ", err)
-			break
-		}
-		if err := todo.AddItem(item); err != nil {
-			fmt.Println("Error: ", err)
-			break
-		}
-		fmt.Println("Ok")
-	case UPDATE_DB_ITEM:
-		fmt.Println("Running UPDATE_DB_ITEM...")
-		item, err := todo.JsonToItem(updateFlag)
-		if err != nil {
-			fmt.Println("Update option requires a valid JSON todo item string")
-			fmt.Println("Error: ", err)
-			break
-		}
-		if err := todo.UpdateItem(item); err != nil {
-			fmt.Println("Error: ", err)
-			break
-		}
-		fmt.Println("Ok")
-	case DELETE_DB_ITEM:
-		fmt.Println("Running DELETE_DB_ITEM...")
-		err := todo.DeleteItem(deleteFlag)
-		if err != nil {
-			fmt.Println("Error: ", err)
-			break
-		}
-		fmt.Println("Ok")
-	case CHANGE_ITEM_STATUS:
-		//For the CHANGE_ITEM_STATUS extra credit you will also
-		//need to add some code here
-		fmt.Println("Running CHANGE_ITEM_STATUS...")
-		err := todo.ChangeItemDoneStatus(queryFlag, itemStatusFlag)
-		if err != nil {
-			fmt.Println("Error: ", err)
-			break
-		}
-		fmt.Println("Ok")
+		name, args = args[0], []string{"-h"}
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args)
+		}
+	}
+
+	fmt.Fprintf(flag.CommandLine.Output(), "Error: unknown command %q\n", name)
+	usage()
+	return errBadUsage
+}
+
+// main is the entry point for our todo CLI application.  It parses the
+// flags shared by every command and then runs the command, which uses the
+// db package to perform the requested operation.  Problems with how a
+// command was called exit with status 2, failures of the command itself
+// with status 1.
+func main() {
+	flag.StringVar(&dbFileNameFlag, "db", "./data/todo.json", "Name of the database file")
+	flag.DurationVar(&lockTimeoutFlag, "lock-timeout", db.DefaultLockTimeout,
+		"How long to wait for another todo using the database before giving up")
+	flag.IntVar(&backupCountFlag, "backups", db.DefaultBackupCount,
+		"How many backups of the database to keep, 0 turns them off")
+	flag.Usage = usage
+	flag.Parse()
+	if backupCountFlag < 0 {
+		fmt.Fprintln(flag.CommandLine.Output(), "Error: -backups cannot be negative")
+		usage()
+		os.Exit(2)
+	}
+
+	err := runCommand(flag.Args())
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errBadUsage):
+		os.Exit(2)
 	default:
-		fmt.Println("INVALID_APP_OPT")
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"drexel.edu/todo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangesAreBackedUp(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	id, err := todo.AddItem(db.ToDoItem{Title: "Backed up"})
+	assert.NoError(t, err)
+	assert.NoError(t, todo.ChangeItemDoneStatus(id, true))
+
+	//A change that fails saves nothing, so it backs nothing up either
+	assert.Error(t, todo.DeleteItem(id+1))
+
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2, "Expected a backup before each change")
+	assert.True(t, backups[0].Time.After(backups[1].Time), "Expected the newest backup first")
+
+	//The newest backup is the database before the item was done
+	assert.NoError(t, todo.RestoreBackup(backups[0]))
+	item, err := todo.GetItem(id)
+	assert.NoError(t, err)
+	assert.False(t, item.IsDone)
+
+	//The oldest is the empty database from before the add
+	assert.NoError(t, todo.RestoreBackup(backups[1]))
+	items, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestRestoreCanBeUndone(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+	_, err = todo.AddItem(db.ToDoItem{Title: "Kept"})
+	assert.NoError(t, err)
+
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.NoError(t, todo.RestoreBackup(backups[0]))
+
+	//Restoring took a backup of the database with the item in it
+	backups, err = todo.Backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	assert.NoError(t, todo.RestoreBackup(backups[0]))
+	items, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestOldBackupsAreRemoved(t *testing.T) {
+	dir := t.TempDir()
+	todo, err := db.New(filepath.Join(dir, "todo.json"))
+	assert.NoError(t, err)
+	todo.SetBackupCount(3)
+
+	for i := 0; i < 6; i++ {
+		_, err := todo.AddItem(db.ToDoItem{Title: fmt.Sprint("Item ", i)})
+		assert.NoError(t, err)
+	}
+
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 3, "Expected only the newest backups to be kept")
+
+	//The oldest one kept is from before the fourth add
+	assert.NoError(t, todo.RestoreBackup(backups[2]))
+	items, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	count := 0
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "todo.json.backup-") {
+			count++
+		}
+	}
+	assert.Equal(t, 3, count, "Expected the removed backups to be gone from disk")
+}
+
+func TestBackupsCanBeTurnedOff(t *testing.T) {
+	todo, err := db.New(filepath.Join(t.TempDir(), "todo.json"))
+	assert.NoError(t, err)
+	todo.SetBackupCount(0)
+
+	_, err = todo.AddItem(db.ToDoItem{Title: "Not backed up"})
+	assert.NoError(t, err)
+
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestBackupsOfOtherDatabasesAreIgnored(t *testing.T) {
+	dir := t.TempDir()
+	todo, err := db.New(filepath.Join(dir, "todo.json"))
+	assert.NoError(t, err)
+	other, err := db.New(filepath.Join(dir, "other.json"))
+	assert.NoError(t, err)
+
+	_, err = other.AddItem(db.ToDoItem{Title: "Elsewhere"})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "todo.json.backup-not-a-time"), nil, 0644))
+
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestRestoreDoesNotReuseIds(t *testing.T) {
+	todo, err := db.New(filepath.Join(t.TempDir(), "todo.json"))
+	assert.NoError(t, err)
+
+	first, err := todo.AddItem(db.ToDoItem{Title: "First"})
+	assert.NoError(t, err)
+	second, err := todo.AddItem(db.ToDoItem{Title: "Second"})
+	assert.NoError(t, err)
+
+	//The newest backup is from before the second item was added
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.NoError(t, todo.RestoreBackup(backups[0]))
+	_, err = todo.GetItem(second)
+	assert.Error(t, err, "Expected the restore to take the second item away")
+
+	next, err := todo.AddItem(db.ToDoItem{Title: "After the restore"})
+	assert.NoError(t, err)
+	assert.Equal(t, second+1, next, "Expected the id given out before the restore to stay used")
+	_, err = todo.GetItem(first)
+	assert.NoError(t, err)
+}
+
+func TestRestoreFromBrokenFile(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+	_, err = todo.AddItem(db.ToDoItem{Title: "Kept"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(dbFile+".bak", []byte("not json"), 0644))
+	assert.Error(t, todo.RestoreDB())
+
+	//Nothing changed, and nothing was backed up
+	items, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+	backups, err := todo.Backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The cli tests build the todo command once and run it against their own
+// copy of the sample database, so they leave ../data/todo.json alone.
+var (
+	buildOnce sync.Once
+	todoBin   string
+	buildErr  error
+)
+
+func buildTodo(t *testing.T) string {
+	buildOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "todo-cli")
+		if err != nil {
+			buildErr = err
+			return
+		}
+		todoBin = filepath.Join(dir, "todo")
+		out, err := exec.Command("go", "build", "-o", todoBin, "..").CombinedOutput()
+		if err != nil {
+			buildErr = errors.New(string(out))
+		}
+	})
+	if buildErr != nil {
+		t.Fatal("building todo:", buildErr)
+	}
+	return todoBin
+}
+
+// newCliDb copies the sample database to a temporary file
+func newCliDb(t *testing.T) string {
+	data, err := os.ReadFile(DEFAULT_DB_FILE_NAME + ".bak")
+	assert.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "todo.json")
+	assert.NoError(t, os.WriteFile(file, data, 0644))
+	return file
+}
+
+// runTodo runs the todo command on the database and returns what it
+// printed and its exit code
+func runTodo(t *testing.T, dbFile string, args ...string) (string, int) {
+	cmd := exec.Command(buildTodo(t), append([]string{"-db", dbFile}, args...)...)
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	assert.NoError(t, err)
+	return string(out), 0
+}
+
+func TestCliAddDoneRemove(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "add", "-id", "20", "Buy", "milk")
+	assert.Equal(t, 0, code, out)
+
+	out, code = runTodo(t, dbFile, "done", "20")
+	assert.Equal(t, 0, code, out)
+
+	out, code = runTodo(t, dbFile, "list", "-done")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, `"title": "Buy milk"`)
+	assert.Contains(t, out, "THERE ARE 1 ITEMS")
+
+	// Flags may follow the id
+	out, code = runTodo(t, dbFile, "done", "20", "-undo")
+	assert.Equal(t, 0, code, out)
+	out, _ = runTodo(t, dbFile, "list", "-done")
+	assert.Contains(t, out, "THERE ARE 0 ITEMS")
+
+	out, code = runTodo(t, dbFile, "rm", "20")
+	assert.Equal(t, 0, code, out)
+
+	out, code = runTodo(t, dbFile, "show", "20")
+	assert.Equal(t, 1, code, "expected the item to be gone")
+	assert.Contains(t, out, "Id not found in db.")
+}
+
+func TestCliAddAssignsId(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "add", "Buy milk")
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "Added item 5\n", out, "expected the id after the sample items")
+
+	out, code = runTodo(t, dbFile, "add", "-id", "3", "Taken")
+	assert.Equal(t, 1, code, out)
+	assert.Contains(t, out, "Item id already exists in db")
+}
+
+// Separate todo processes adding at once each get their own id and none of
+// the adds is lost
+func TestCliConcurrentAdds(t *testing.T) {
+	dbFile := newCliDb(t)
+	const adders = 10
+
+	var wg sync.WaitGroup
+	for i := 0; i < adders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, code := runTodo(t, dbFile, "add", "Concurrent")
+			assert.Equal(t, 0, code, out)
+		}()
+	}
+	wg.Wait()
+
+	out, code := runTodo(t, dbFile, "list")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, "THERE ARE 14 ITEMS", "expected the sample items and every add")
+
+	out, _ = runTodo(t, dbFile, "add", "Last")
+	assert.Equal(t, "Added item 15\n", out)
+}
+
+func TestCliBackups(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "backups")
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "THERE ARE 0 BACKUPS\n", out)
+
+	runTodo(t, dbFile, "add", "-id", "20", "First")
+	runTodo(t, dbFile, "add", "-id", "21", "Second")
+
+	out, code = runTodo(t, dbFile, "backups")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, "THERE ARE 2 BACKUPS")
+	lines := strings.Split(out, "\n")
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(lines[0]), "1  "), out)
+	oldest := strings.Fields(lines[1])[1]
+
+	// By number, the newest backup undoes the last add
+	out, code = runTodo(t, dbFile, "restore", "-from", "1")
+	assert.Equal(t, 0, code, out)
+	out, _ = runTodo(t, dbFile, "list")
+	assert.Contains(t, out, "THERE ARE 5 ITEMS")
+
+	// By stamp, with the double dash spelling
+	out, code = runTodo(t, dbFile, "restore", "--from", oldest)
+	assert.Equal(t, 0, code, out)
+	assert.Equal(t, "Database restored from backup "+oldest+"\n", out)
+	out, _ = runTodo(t, dbFile, "list")
+	assert.Contains(t, out, "THERE ARE 4 ITEMS")
+
+	// Restoring doesn't set the id counter back, the ids given out are
+	// still used
+	out, _ = runTodo(t, dbFile, "add", "Again")
+	assert.Equal(t, "Added item 22\n", out)
+
+	out, code = runTodo(t, dbFile, "restore", "-from", "9")
+	assert.Equal(t, 1, code, out)
+	assert.Contains(t, out, "Error: there is no backup 9, there are 5")
+
+	// -backups limits how many are kept
+	out, code = runTodo(t, dbFile, "-backups", "1", "add", "Third")
+	assert.Equal(t, 0, code, out)
+	out, _ = runTodo(t, dbFile, "backups")
+	assert.Contains(t, out, "THERE ARE 1 BACKUPS")
+}
+
+func TestCliUpdate(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "update", "2", "-title", "Learn Helm")
+	assert.Equal(t, 0, code, out)
+
+	out, code = runTodo(t, dbFile, "show", "2")
+	assert.Equal(t, 0, code, out)
+	assert.Contains(t, out, `"title": "Learn Helm"`)
+}
+
+func TestCliItemFields(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "add", "-due", "2026-11-01T17:00:00Z", "-priority", "2",
+		"-tags", "work, release,work", "-notes", "Check the changelog", "Plan", "the", "release")
+	assert.Equal(t, 0, code, out)
+
+	out, _ = runTodo(t, dbFile, "show", "5")
+	assert.Contains(t, out, `"due": "2026-11-01T17:00:00Z"`)
+	assert.Contains(t, out, `"priority": 2`)
+	assert.Contains(t, out, "\"tags\": [\n    \"work\",\n    \"release\"\n  ]")
+	assert.Contains(t, out, `"notes": "Check the changelog"`)
+	assert.Contains(t, out, `"created_at"`)
+	assert.NotContains(t, out, `"completed_at"`)
+
+	// Only the fields given change, and empty ones are cleared
+	out, code = runTodo(t, dbFile, "update", "5", "-priority", "0", "-tags", "", "-notes", "Done soon")
+	assert.Equal(t, 0, code, out)
+	out, _ = runTodo(t, dbFile, "show", "5")
+	assert.Contains(t, out, `"title": "Plan the release"`)
+	assert.Contains(t, out, `"due": "2026-11-01T17:00:00Z"`)
+	assert.Contains(t, out, `"notes": "Done soon"`)
+	assert.NotContains(t, out, `"priority"`)
+	assert.NotContains(t, out, `"tags"`)
+
+	runTodo(t, dbFile, "done", "5")
+	out, _ = runTodo(t, dbFile, "show", "5")
+	assert.Contains(t, out, `"completed_at"`)
+}
+
+func TestCliBadUsage(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	for _, args := range [][]string{
+		{},
+		{"bogus"},
+		{"list", "-done", "-open"},
+		{"list", "extra"},
+		{"show"},
+		{"show", "1", "2"},
+		{"rm", "x"},
+		{"done", "-1"},
+		{"add", "-id", "20"},
+		{"add", "-id", "-2", "Negative id"},
+		{"update", "1"},
+		{"update", "1", "-title", ""},
+		{"update", "1", "-priority", "6"},
+		{"add", "-due", "tomorrow", "Vague"},
+		{"restore", "now"},
+		{"-backups", "-1", "list"},
+		{"done", "-bogus", "1"},
+	} {
+		out, code := runTodo(t, dbFile, args...)
+		assert.Equal(t, 2, code, "expected %v to be refused: %s", args, out)
+		assert.Contains(t, out, "Usage: todo", "expected the usage for %v", args)
+	}
+
+	data, err := os.ReadFile(dbFile)
+	assert.NoError(t, err)
+	sample, err := os.ReadFile(DEFAULT_DB_FILE_NAME + ".bak")
+	assert.NoError(t, err)
+	assert.Equal(t, string(sample), string(data), "expected refused commands to leave the database alone")
+}
+
+func TestCliHelp(t *testing.T) {
+	dbFile := newCliDb(t)
+
+	out, code := runTodo(t, dbFile, "help")
+	assert.Equal(t, 0, code)
+	for _, name := range []string{"list", "show", "add", "update", "done", "rm", "restore"} {
+		assert.Contains(t, out, "  "+name+" ")
+	}
+
+	out, code = runTodo(t, dbFile, "help", "done")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "-undo")
+
+	out, code = runTodo(t, dbFile, "rm", "-h")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, out, "Usage: todo [-db file] rm <id>")
+}
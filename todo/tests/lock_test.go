@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"drexel.edu/todo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// Each goroutine opens the database on its own, like separate todo
+// processes would, so only the lock keeps their adds from overwriting each
+// other
+func TestConcurrentAddsAreAllSaved(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	const adders = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < adders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			todo, err := db.New(dbFile)
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = todo.AddItem(db.ToDoItem{Title: fmt.Sprint("Item ", i)})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+	items, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, items, adders, "Expected every add to be saved")
+
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+	sort.Ints(ids)
+	for i, id := range ids {
+		assert.Equal(t, i+1, id, "Expected each add to get its own id")
+	}
+}
+
+// ChangeItemDoneStatus reads and writes the item under one lock, so marking
+// different items done at the same time loses none of them
+func TestConcurrentDoneStatusChanges(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	const items = 10
+	for i := 0; i < items; i++ {
+		_, err := todo.AddItem(db.ToDoItem{Title: fmt.Sprint("Item ", i)})
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for id := 1; id <= items; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			other, err := db.New(dbFile)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, other.ChangeItemDoneStatus(id, true))
+		}(id)
+	}
+	wg.Wait()
+
+	all, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, all, items)
+	for _, item := range all {
+		assert.True(t, item.IsDone, "Expected item %d to be done", item.Id)
+	}
+}
+
+// UpdateItemFields edits the item under one lock too, so editing items
+// while they are marked done loses neither change
+func TestConcurrentFieldUpdatesAndDoneStatusChanges(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	const items = 10
+	for i := 0; i < items; i++ {
+		_, err := todo.AddItem(db.ToDoItem{Title: fmt.Sprint("Item ", i)})
+		assert.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for id := 1; id <= items; id++ {
+		wg.Add(2)
+		go func(id int) {
+			defer wg.Done()
+			other, err := db.New(dbFile)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, other.ChangeItemDoneStatus(id, true))
+		}(id)
+		go func(id int) {
+			defer wg.Done()
+			other, err := db.New(dbFile)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, other.UpdateItemFields(id, func(item *db.ToDoItem) {
+				item.Notes = "Edited"
+			}))
+		}(id)
+	}
+	wg.Wait()
+
+	all, err := todo.GetAllItems()
+	assert.NoError(t, err)
+	assert.Len(t, all, items)
+	for _, item := range all {
+		assert.True(t, item.IsDone, "Expected item %d to be done", item.Id)
+		assert.NotNil(t, item.CompletedAt, "Expected item %d to keep when it was completed", item.Id)
+		assert.Equal(t, "Edited", item.Notes, "Expected item %d to keep its edit", item.Id)
+	}
+}
+
+func TestLoadDropsDeletedItems(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	first, err := db.New(dbFile)
+	assert.NoError(t, err)
+	second, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	id, err := first.AddItem(db.ToDoItem{Title: "Short lived"})
+	assert.NoError(t, err)
+	_, err = second.GetItem(id)
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.DeleteItem(id))
+	_, err = second.GetItem(id)
+	assert.Error(t, err, "Expected the item deleted through the other ToDo to be gone")
+}
+
+func TestSaveLeavesNoTemporaryFiles(t *testing.T) {
+	dir := t.TempDir()
+	todo, err := db.New(filepath.Join(dir, "todo.json"))
+	assert.NoError(t, err)
+
+	id, err := todo.AddItem(db.ToDoItem{Title: "Saved"})
+	assert.NoError(t, err)
+	assert.NoError(t, todo.ChangeItemDoneStatus(id, true))
+	_, err = todo.AddItem(db.ToDoItem{Id: id, Title: "Clashes"})
+	assert.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.Name(), ".tmp-", "Expected the temporary file to be renamed or removed")
+	}
+
+	//The file is whole and holds the item
+	reopened, err := db.New(filepath.Join(dir, "todo.json"))
+	assert.NoError(t, err)
+	item, err := reopened.GetItem(id)
+	assert.NoError(t, err)
+	assert.True(t, item.IsDone)
+}
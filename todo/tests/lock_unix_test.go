@@ -0,0 +1,76 @@
+//go:build unix
+
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"drexel.edu/todo/db"
+	"github.com/stretchr/testify/assert"
+)
+
+// holdLock takes the lock on the database the way another todo process
+// would, and returns the function that releases it
+func holdLock(t *testing.T, dbFile string) func() {
+	f, err := os.OpenFile(dbFile+".lock", os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		t.Fatal(err)
+	}
+	return func() { f.Close() }
+}
+
+func TestLockTimesOut(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+	todo.SetLockTimeout(100 * time.Millisecond)
+
+	release := holdLock(t, dbFile)
+
+	start := time.Now()
+	_, err = todo.AddItem(db.ToDoItem{Title: "Blocked"})
+	assert.ErrorIs(t, err, db.ErrLocked)
+	assert.Contains(t, err.Error(), "todo.json.lock is held by another todo process")
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "Expected to wait for the lock")
+
+	assert.ErrorIs(t, todo.ChangeItemDoneStatus(1, true), db.ErrLocked)
+
+	//Reads don't need the lock, a save never leaves the file half written
+	_, err = todo.GetAllItems()
+	assert.NoError(t, err)
+
+	release()
+	id, err := todo.AddItem(db.ToDoItem{Title: "Unblocked"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id, "Expected the blocked add to have saved nothing")
+}
+
+func TestLockWaitsForRelease(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	release := holdLock(t, dbFile)
+	time.AfterFunc(100*time.Millisecond, release)
+
+	_, err = todo.AddItem(db.ToDoItem{Title: "Waited"})
+	assert.NoError(t, err, "Expected the add to get the lock once it was released")
+}
+
+func TestCliLockTimeout(t *testing.T) {
+	dbFile := newCliDb(t)
+	release := holdLock(t, dbFile)
+	defer release()
+
+	out, code := runTodo(t, dbFile, "-lock-timeout", "50ms", "add", "Blocked")
+	assert.Equal(t, 1, code, out)
+	assert.Contains(t, out, "Error: database is locked")
+	assert.Contains(t, out, "gave up after 50ms")
+}
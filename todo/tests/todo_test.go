@@ -11,7 +11,9 @@ package tests
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"drexel.edu/todo/db"
 	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
@@ -73,14 +75,19 @@ func TestAddHardCodedItem(t *testing.T) {
 	//I will get you started, uncomment the lines below to add to the DB
 	//and ensure no errors:
 	//---------------------------------------------------------------
-	err := DB.AddItem(item)
+	id, err := DB.AddItem(item)
 	assert.NoError(t, err, "Error adding item to DB")
+	assert.Equal(t, item.Id, id, "Expected the item to keep its id")
 
 	//TODO: Now finish the test case by looking up the item in the DB
 	//and making sure it matches the item that you put in the DB above
 	dbItem, err := DB.GetItem(item.Id)
 	assert.NoError(t, err, "Error getting item from DB")
 
+	//Adding the item stamped it with the time it was created
+	assert.NotNil(t, dbItem.CreatedAt, "Expected the item to get a creation time")
+	item.CreatedAt = dbItem.CreatedAt
+
 	assert.Equal(t, item, dbItem, "Items don't match")
 }
 
@@ -94,7 +101,8 @@ func TestAddRandomStructItem(t *testing.T) {
 	assert.NoError(t, err, "Created fake item OK")
 
 	//TODO: Complete the test
-	err = DB.AddItem(item)
+	//A random id of 0 gets the next free id instead
+	item.Id, err = DB.AddItem(item)
 	assert.NoError(t, err, "Error adding item to DB")
 
 	dbItem, err := DB.GetItem(item.Id)
@@ -112,12 +120,19 @@ func TestAddRandomItem(t *testing.T) {
 	}
 
 	t.Log("Testing Adding an Item with Random Fields: ", item)
-	err := DB.AddItem(item)
+	_, err := DB.AddItem(item)
 	assert.NoError(t, err, "Error adding item to DB")
 
 	dbItem, err := DB.GetItem(item.Id)
 	assert.NoError(t, err, "Error getting item from DB")
 
+	//Adding the item stamped it with the time it was created, and
+	//completed if it is done
+	assert.NotNil(t, dbItem.CreatedAt, "Expected the item to get a creation time")
+	assert.Equal(t, item.IsDone, dbItem.CompletedAt != nil, "Expected a completion time only for a done item")
+	item.CreatedAt = dbItem.CreatedAt
+	item.CompletedAt = dbItem.CompletedAt
+
 	assert.Equal(t, item, dbItem, "Items don't match")
 }
 
@@ -132,7 +147,7 @@ func TestAddItemWithExistingId(t *testing.T) {
 		IsDone: false,
 	}
 
-	err := DB.AddItem(item)
+	_, err := DB.AddItem(item)
 
 	assert.EqualError(t, err, "Item id already exists in db")
 }
@@ -169,7 +184,7 @@ func TestDeleteItem(t *testing.T) {
 		IsDone: false,
 	}
 
-	err := DB.AddItem(item)
+	_, err := DB.AddItem(item)
 	assert.NoError(t, err, "Error adding item.")
 
 	err = DB.DeleteItem(998)
@@ -231,3 +246,175 @@ func TestChangeItemDoneStatus(t *testing.T) {
 	assert.Equal(t, true, item.IsDone, "Item status not updated.")
 
 }
+
+func TestAddItemAssignsId(t *testing.T) {
+	items, err := DB.GetAllItems()
+	assert.NoError(t, err)
+	highest := 0
+	for _, item := range items {
+		highest = max(highest, item.Id)
+	}
+
+	id, err := DB.AddItem(db.ToDoItem{Title: "Buy milk"})
+	assert.NoError(t, err, "Error adding item without an id.")
+	assert.Greater(t, id, highest, "Expected an id no item has")
+
+	item, err := DB.GetItem(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Buy milk", item.Title)
+
+	//Ids of deleted items are not handed out again
+	assert.NoError(t, DB.DeleteItem(id))
+	next, err := DB.AddItem(db.ToDoItem{Title: "Buy bread"})
+	assert.NoError(t, err)
+	assert.Equal(t, id+1, next, "Expected the deleted item's id to be skipped")
+	assert.NoError(t, DB.DeleteItem(next))
+}
+
+func TestIdCounterIsSaved(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	first, err := todo.AddItem(db.ToDoItem{Title: "First"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, first, "Expected a new database to start at 1")
+
+	_, err = todo.AddItem(db.ToDoItem{Id: 10, Title: "Picked id"})
+	assert.NoError(t, err)
+	assert.NoError(t, todo.DeleteItem(10))
+
+	//A fresh ToDo reads the counter back from the file
+	reopened, err := db.New(dbFile)
+	assert.NoError(t, err)
+	next, err := reopened.AddItem(db.ToDoItem{Title: "Next"})
+	assert.NoError(t, err)
+	assert.Equal(t, 11, next, "Expected ids to continue after the highest one ever used")
+}
+
+func TestLoadItemArrayFile(t *testing.T) {
+	//Databases written before the id counter are just the array of items
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	data := `[{"id": 4, "title": "Old item", "done": true}]`
+	assert.NoError(t, os.WriteFile(dbFile, []byte(data), 0644))
+
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	item, err := todo.GetItem(4)
+	assert.NoError(t, err)
+	assert.Equal(t, db.ToDoItem{Id: 4, Title: "Old item", IsDone: true}, item)
+
+	id, err := todo.AddItem(db.ToDoItem{Title: "New item"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, id)
+}
+
+func TestItemFieldsAreSaved(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+
+	due := time.Date(2026, time.November, 1, 17, 0, 0, 0, time.UTC)
+	created := time.Date(2026, time.October, 1, 9, 0, 0, 0, time.UTC)
+	item := db.ToDoItem{
+		Title:     "Plan the release",
+		Due:       &due,
+		Priority:  2,
+		Tags:      []string{"work", "release"},
+		Notes:     "Check the changelog first",
+		CreatedAt: &created,
+	}
+	item.Id, err = todo.AddItem(item)
+	assert.NoError(t, err)
+
+	//A fresh ToDo reads every field back from the file
+	reopened, err := db.New(dbFile)
+	assert.NoError(t, err)
+	dbItem, err := reopened.GetItem(item.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, item, dbItem, "Expected the given creation time to be kept")
+}
+
+func TestChangeItemDoneStatusSetsCompletedAt(t *testing.T) {
+	todo, err := db.New(filepath.Join(t.TempDir(), "todo.json"))
+	assert.NoError(t, err)
+
+	before := time.Now().Truncate(time.Second)
+	id, err := todo.AddItem(db.ToDoItem{Title: "Finish me"})
+	assert.NoError(t, err)
+
+	item, err := todo.GetItem(id)
+	assert.NoError(t, err)
+	assert.Nil(t, item.CompletedAt)
+	if assert.NotNil(t, item.CreatedAt) {
+		assert.False(t, item.CreatedAt.Before(before), "Expected the item to be created now")
+	}
+
+	assert.NoError(t, todo.ChangeItemDoneStatus(id, true))
+	item, err = todo.GetItem(id)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item.CompletedAt, "Expected marking the item done to set when") {
+		assert.False(t, item.CompletedAt.Before(before))
+	}
+
+	//Marking it done again keeps when it was first completed
+	earlier := time.Date(2026, time.October, 1, 9, 0, 0, 0, time.UTC)
+	item.CompletedAt = &earlier
+	assert.NoError(t, todo.UpdateItem(item))
+	assert.NoError(t, todo.ChangeItemDoneStatus(id, true))
+	item, err = todo.GetItem(id)
+	assert.NoError(t, err)
+	assert.Equal(t, &earlier, item.CompletedAt, "Expected the completion time to be left alone")
+
+	assert.NoError(t, todo.ChangeItemDoneStatus(id, false))
+	item, err = todo.GetItem(id)
+	assert.NoError(t, err)
+	assert.Nil(t, item.CompletedAt, "Expected marking the item not done to clear when it was completed")
+}
+
+func TestItemsWithoutNewFieldsLoad(t *testing.T) {
+	//The sample database only has ids, titles and done flags
+	item, err := DB.GetItem(2)
+	assert.NoError(t, err)
+	assert.Nil(t, item.Due)
+	assert.Zero(t, item.Priority)
+	assert.Nil(t, item.Tags)
+	assert.Nil(t, item.CreatedAt)
+
+	//Fields that are not set are left out of the file
+	dbFile := filepath.Join(t.TempDir(), "todo.json")
+	todo, err := db.New(dbFile)
+	assert.NoError(t, err)
+	created := time.Date(2026, time.October, 1, 9, 0, 0, 0, time.UTC)
+	_, err = todo.AddItem(db.ToDoItem{Title: "Plain", CreatedAt: &created})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(dbFile)
+	assert.NoError(t, err)
+	for _, field := range []string{"due", "priority", "tags", "notes", "completed_at"} {
+		assert.NotContains(t, string(data), `"`+field+`"`)
+	}
+}
+
+func TestUpdateItemFields(t *testing.T) {
+	todo, err := db.New(filepath.Join(t.TempDir(), "todo.json"))
+	assert.NoError(t, err)
+	id, err := todo.AddItem(db.ToDoItem{Title: "Old title", Priority: 3})
+	assert.NoError(t, err)
+
+	err = todo.UpdateItemFields(id, func(item *db.ToDoItem) {
+		item.Title = "New title"
+		item.Id = id + 1
+	})
+	assert.NoError(t, err)
+
+	item, err := todo.GetItem(id)
+	assert.NoError(t, err, "Expected the edit to leave the id alone")
+	assert.Equal(t, "New title", item.Title)
+	assert.Equal(t, 3, item.Priority, "Expected the fields the edit left alone to be kept")
+	assert.NotNil(t, item.CreatedAt)
+
+	err = todo.UpdateItemFields(id+1, func(item *db.ToDoItem) { item.Title = "Missing" })
+	assert.EqualError(t, err, "Item id does not exist in db")
+}